@@ -0,0 +1,39 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package command
+
+import "bytes"
+
+// limitedBuffer keeps the first limit bytes and discards the rest,
+// it never returns an error so that the command is not blocked on a full pipe.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int64
+	truncated bool
+}
+
+func newLimitedBuffer(limit int64) *limitedBuffer {
+	return &limitedBuffer{limit: limit}
+}
+
+// Write implements io.Writer.
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remain := b.limit - int64(b.buf.Len()); remain < int64(len(p)) {
+		b.truncated = true
+
+		if remain > 0 {
+			b.buf.Write(p[:remain])
+		}
+
+		return len(p), nil
+	}
+
+	return b.buf.Write(p)
+}
+
+// String returns the kept content.
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
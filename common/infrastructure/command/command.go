@@ -0,0 +1,102 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package command
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// waitDelay is how long to wait for the output pipes to be closed after the process is killed,
+// the children of the command may still hold them.
+const waitDelay = 10 * time.Second
+
+// Cmd describes an external command, no timeout is set when Timeout is zero.
+type Cmd struct {
+	Name    string
+	Args    []string
+	Timeout time.Duration
+}
+
+// String returns the command line.
+func (c *Cmd) String() string {
+	return strings.Join(append([]string{c.Name}, c.Args...), " ")
+}
+
+// Runner runs external commands.
+type Runner interface {
+	// Run returns stdout of the command, the output read so far is also returned on failure.
+	Run(ctx context.Context, c Cmd) (string, error)
+}
+
+// NewRunner creates a Runner which kills the whole process group of the command when ctx is done or timeout.
+func NewRunner(cfg *Config) *runner {
+	return &runner{
+		maxOutput: cfg.maxOutputBytes(),
+		maxStderr: cfg.maxStderrBytes(),
+	}
+}
+
+type runner struct {
+	maxOutput int64
+	maxStderr int64
+}
+
+// Run runs the command and waits for it to finish.
+func (r *runner) Run(ctx context.Context, c Cmd) (string, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	stdout := newLimitedBuffer(r.maxOutput)
+	stderr := newLimitedBuffer(r.maxStderr)
+
+	cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = waitDelay
+	setProcessGroup(cmd)
+
+	err := cmd.Run()
+	out := stdout.String()
+
+	if err == nil && stdout.truncated {
+		err = ErrOutputTooLarge
+	}
+
+	if err == nil {
+		return out, nil
+	}
+
+	return out, r.newError(ctx, &c, cmd, stderr.String(), err)
+}
+
+func (r *runner) newError(ctx context.Context, c *Cmd, cmd *exec.Cmd, stderr string, err error) error {
+	e := &Error{
+		Cmd:      c.String(),
+		ExitCode: -1,
+		Stderr:   stderr,
+		Err:      err,
+	}
+
+	if cmd.ProcessState != nil {
+		e.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	// the error of a killed process is only "signal: killed", report the reason instead
+	switch ctxErr := ctx.Err(); {
+	case errors.Is(ctxErr, context.DeadlineExceeded):
+		e.Err = ErrTimeout
+	case ctxErr != nil:
+		e.Err = ctxErr
+	}
+
+	return e
+}
@@ -0,0 +1,37 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+// Package command provides functionality for running external commands.
+package command
+
+const (
+	defaultMaxOutput = 256
+	defaultMaxStderr = 64
+)
+
+// Config represents the configuration for running external commands.
+// MaxOutput limits the size of stdout in MB, MaxStderr limits the size of stderr in KB.
+type Config struct {
+	MaxOutput int64 `json:"max_output"`
+	MaxStderr int64 `json:"max_stderr"`
+}
+
+// SetDefault sets the default values for the Config.
+func (cfg *Config) SetDefault() {
+	if cfg.MaxOutput <= 0 {
+		cfg.MaxOutput = defaultMaxOutput
+	}
+
+	if cfg.MaxStderr <= 0 {
+		cfg.MaxStderr = defaultMaxStderr
+	}
+}
+
+func (cfg *Config) maxOutputBytes() int64 {
+	return cfg.MaxOutput << 20
+}
+
+func (cfg *Config) maxStderrBytes() int64 {
+	return cfg.MaxStderr << 10
+}
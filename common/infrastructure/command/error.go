@@ -0,0 +1,52 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrTimeout is returned when the command is killed because it runs longer than Cmd.Timeout.
+	ErrTimeout = errors.New("command timed out")
+
+	// ErrOutputTooLarge is returned when stdout exceeds Config.MaxOutput, the output is truncated.
+	ErrOutputTooLarge = errors.New("command output is too large")
+)
+
+// Error describes a failed command, ExitCode is -1 when the command is not started or killed by a signal.
+type Error struct {
+	Cmd      string
+	ExitCode int
+	Stderr   string
+	Err      error
+}
+
+// Error returns the command, exit code and stderr of the failed command.
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("command [%s] failed with exit code %d: %s", e.Cmd, e.ExitCode, e.Err.Error())
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		msg += ", stderr: " + stderr
+	}
+
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code of a command error, -1 if err is not an *Error.
+func ExitCode(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.ExitCode
+	}
+
+	return -1
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+package command
+
+import "os/exec"
+
+// setProcessGroup only the command itself is killed on cancel.
+func setProcessGroup(cmd *exec.Cmd) {}
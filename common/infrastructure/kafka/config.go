@@ -0,0 +1,44 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+// Package kafka provides functionality for publishing messages to Kafka.
+package kafka
+
+import (
+	"strings"
+	"time"
+)
+
+// Config represents the configuration for Kafka, publishing is disabled when Address is empty.
+type Config struct {
+	Address string `json:"address"`
+	Version string `json:"version"`
+	Timeout int    `json:"timeout"` // the unit is second
+}
+
+// SetDefault sets the default values for the Config.
+func (cfg *Config) SetDefault() {
+	if cfg.Version == "" {
+		cfg.Version = "2.1.0"
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10
+	}
+}
+
+func (cfg *Config) brokers() []string {
+	var brokers []string
+	for _, v := range strings.Split(cfg.Address, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			brokers = append(brokers, v)
+		}
+	}
+
+	return brokers
+}
+
+func (cfg *Config) getTimeout() time.Duration {
+	return time.Second * time.Duration(cfg.Timeout)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+// Package kafka provides functionality for publishing messages to Kafka.
+package kafka
+
+import (
+	"github.com/IBM/sarama"
+)
+
+var producer sarama.SyncProducer
+
+// Init initializes the kafka producer, nothing is done when no address is configured.
+func Init(cfg *Config) error {
+	brokers := cfg.brokers()
+	if len(brokers) == 0 {
+		return nil
+	}
+
+	version, err := sarama.ParseKafkaVersion(cfg.Version)
+	if err != nil {
+		return err
+	}
+
+	config := sarama.NewConfig()
+	config.Version = version
+	config.Net.DialTimeout = cfg.getTimeout()
+	config.Producer.Timeout = cfg.getTimeout()
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Return.Successes = true
+
+	p, err := sarama.NewSyncProducer(brokers, config)
+	if err != nil {
+		return err
+	}
+
+	producer = p
+
+	return nil
+}
+
+// Enabled reports whether the producer has been initialized.
+func Enabled() bool {
+	return producer != nil
+}
+
+// Publish sends the message to the topic, messages with the same key go to the same partition.
+func Publish(topic, key string, header map[string]string, value []byte) error {
+	if producer == nil {
+		return nil
+	}
+
+	msg := &sarama.ProducerMessage{
+		Topic: topic,
+		Value: sarama.ByteEncoder(value),
+	}
+
+	if key != "" {
+		msg.Key = sarama.StringEncoder(key)
+	}
+
+	for k, v := range header {
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{
+			Key:   []byte(k),
+			Value: []byte(v),
+		})
+	}
+
+	_, _, err := producer.SendMessage(msg)
+
+	return err
+}
+
+// Exit closes the producer.
+func Exit() error {
+	if producer == nil {
+		return nil
+	}
+
+	return producer.Close()
+}
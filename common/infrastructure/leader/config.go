@@ -0,0 +1,36 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+// Package leader provides leader election between replicas.
+package leader
+
+import "time"
+
+const (
+	// defaultLockKey is "imgs" in ASCII, shared by all replicas of the service.
+	defaultLockKey  = 0x696d6773
+	defaultInterval = 10
+)
+
+// Config represents the configuration for leader election.
+// Interval is the period in seconds to acquire or check the leadership.
+type Config struct {
+	LockKey  int64 `json:"lock_key"`
+	Interval int   `json:"interval"`
+}
+
+// SetDefault sets the default values for the Config.
+func (cfg *Config) SetDefault() {
+	if cfg.LockKey == 0 {
+		cfg.LockKey = defaultLockKey
+	}
+
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+}
+
+func (cfg *Config) interval() time.Duration {
+	return time.Duration(cfg.Interval) * time.Second
+}
@@ -0,0 +1,112 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+// Package leader provides leader election between replicas.
+package leader
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Locker is a lock held by at most one replica at a time.
+type Locker interface {
+	TryLock(ctx context.Context) (bool, error)
+	Check(ctx context.Context) error
+	Unlock(ctx context.Context) error
+}
+
+// NewElector creates an Elector which competes for the lock periodically.
+func NewElector(cfg *Config, lock Locker) *Elector {
+	return &Elector{
+		cfg:  cfg,
+		lock: lock,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Elector makes the replica holding the lock the leader.
+// The leadership is lost when the lock can not be checked, for example when the database connection is broken.
+type Elector struct {
+	cfg    *Config
+	lock   Locker
+	leader atomic.Bool
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// Start tries to acquire the leadership once before returning, then keeps competing in the background.
+func (e *Elector) Start() {
+	e.elect()
+
+	go func() {
+		defer close(e.done)
+
+		ticker := time.NewTicker(e.cfg.interval())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-e.stop:
+				return
+			case <-ticker.C:
+				e.elect()
+			}
+		}
+	}()
+}
+
+// IsLeader reports whether the replica is the leader.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Stop stops competing and releases the leadership so that another replica can take over immediately.
+func (e *Elector) Stop(ctx context.Context) {
+	e.stopOnce.Do(func() { close(e.stop) })
+
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+	}
+
+	e.leader.Store(false)
+
+	if err := e.lock.Unlock(ctx); err != nil {
+		logrus.Errorf("release leadership failed: %s", err.Error())
+	}
+}
+
+func (e *Elector) elect() {
+	ctx, cancel := context.WithTimeout(context.Background(), e.cfg.interval())
+	defer cancel()
+
+	if e.leader.Load() {
+		if err := e.lock.Check(ctx); err != nil {
+			e.leader.Store(false)
+			logrus.Warnf("leadership lost: %s", err.Error())
+		}
+
+		return
+	}
+
+	ok, err := e.lock.TryLock(ctx)
+	if err != nil {
+		logrus.Errorf("acquire leadership failed: %s", err.Error())
+
+		return
+	}
+
+	if ok {
+		e.leader.Store(true)
+		logrus.Info("became the leader")
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+// Package postgresql provides functionality for interacting with PostgreSQL databases.
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+)
+
+// ErrLockNotHeld is returned when the session holding the advisory lock is gone.
+var ErrLockNotHeld = errors.New("advisory lock is not held")
+
+// NewAdvisoryLock creates a session level advisory lock identified by key.
+func NewAdvisoryLock(key int64) *AdvisoryLock {
+	return &AdvisoryLock{key: key}
+}
+
+// AdvisoryLock holds a dedicated connection while the lock is acquired,
+// because session level advisory locks are released when the connection is closed.
+type AdvisoryLock struct {
+	key int64
+
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// TryLock tries to acquire the lock without blocking.
+func (l *AdvisoryLock) TryLock(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn != nil {
+		return true, nil
+	}
+
+	if db == nil {
+		return false, errors.New("empty pointer of *gorm.DB")
+	}
+
+	sqlDb, err := db.DB()
+	if err != nil {
+		return false, err
+	}
+
+	conn, err := sqlDb.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var ok bool
+	if err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&ok); err != nil || !ok {
+		conn.Close()
+
+		return false, err
+	}
+
+	l.conn = conn
+
+	return true, nil
+}
+
+// Check verifies that the session of the connection still holds the lock.
+func (l *AdvisoryLock) Check(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return ErrLockNotHeld
+	}
+
+	classid, objid := splitLockKey(l.key)
+
+	var held bool
+	err := l.conn.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM pg_locks WHERE locktype = 'advisory' AND pid = pg_backend_pid()
+		AND classid = $1::bigint::oid AND objid = $2::bigint::oid AND objsubid = 1)`,
+		classid, objid,
+	).Scan(&held)
+	if err == nil && !held {
+		err = ErrLockNotHeld
+	}
+
+	if err != nil {
+		l.close()
+	}
+
+	return err
+}
+
+// splitLockKey splits a bigint key the way PostgreSQL stores it in pg_locks: classid is the high 32 bits
+// and objid the low 32 bits, both unsigned, so a negative key does not overflow the oid columns.
+func splitLockKey(key int64) (classid, objid int64) {
+	u := uint64(key)
+
+	return int64(u >> 32), int64(u & 0xffffffff)
+}
+
+// Unlock releases the lock and the dedicated connection.
+func (l *AdvisoryLock) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return nil
+	}
+
+	_, err := l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key)
+	l.close()
+
+	return err
+}
+
+func (l *AdvisoryLock) close() {
+	_ = l.conn.Close()
+	l.conn = nil
+}
@@ -0,0 +1,23 @@
+package postgresql
+
+import "testing"
+
+func TestSplitLockKey(t *testing.T) {
+	cases := []struct {
+		key            int64
+		classid, objid int64
+	}{
+		{0x696d6773, 0, 0x696d6773},
+		{0x100000002, 1, 2},
+		{-1, 0xffffffff, 0xffffffff},
+		{-0x100000000, 0xffffffff, 0},
+		{-9223372036854775808, 0x80000000, 0},
+	}
+
+	for _, c := range cases {
+		classid, objid := splitLockKey(c.key)
+		if classid != c.classid || objid != c.objid {
+			t.Errorf("splitLockKey(%d) = (%d, %d), want (%d, %d)", c.key, classid, objid, c.classid, c.objid)
+		}
+	}
+}
@@ -6,6 +6,7 @@ Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
 package postgresql
 
 import (
+	"context"
 	"errors"
 	"log"
 	"os"
@@ -82,6 +83,20 @@ func DB() *gorm.DB {
 	return db
 }
 
+// Ping checks whether the database is reachable.
+func Ping(ctx context.Context) error {
+	if db == nil {
+		return errors.New("empty pointer of *gorm.DB")
+	}
+
+	sqlDb, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDb.PingContext(ctx)
+}
+
 // AutoMigrate automatically migrates the given table.
 func AutoMigrate(table interface{}) error {
 	// pointer non-nil check
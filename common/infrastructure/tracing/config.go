@@ -0,0 +1,26 @@
+/*
+Copyright (c) Huawei Technologies Co., Ltd. 2024. All rights reserved
+*/
+
+// Package tracing provides functionality for exporting OpenTelemetry traces.
+package tracing
+
+// Config represents the configuration for tracing, tracing is disabled when Endpoint is empty.
+type Config struct {
+	// Endpoint is the url of the OTLP/HTTP collector, e.g. http://otel-collector:4318
+	Endpoint    string            `json:"endpoint"`
+	Headers     map[string]string `json:"headers"`
+	ServiceName string            `json:"service_name"`
+	SampleRatio float64           `json:"sample_ratio"`
+}
+
+// SetDefault sets the default values for the Config.
+func (cfg *Config) SetDefault() {
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "image-scanning"
+	}
+
+	if cfg.SampleRatio <= 0 || cfg.SampleRatio > 1 {
+		cfg.SampleRatio = 1
+	}
+}
@@ -4,9 +4,16 @@ import (
 	"os"
 
 	common "github.com/opensourceways/image-scanning/common/config"
+	"github.com/opensourceways/image-scanning/common/infrastructure/command"
+	"github.com/opensourceways/image-scanning/common/infrastructure/kafka"
+	"github.com/opensourceways/image-scanning/common/infrastructure/leader"
 	"github.com/opensourceways/image-scanning/common/infrastructure/postgresql"
+	"github.com/opensourceways/image-scanning/common/infrastructure/tracing"
 	"github.com/opensourceways/image-scanning/scanning/app"
+	"github.com/opensourceways/image-scanning/scanning/controller"
 	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/infrastructure/messageimpl"
+	"github.com/opensourceways/image-scanning/scanning/infrastructure/ratelimiterimpl"
 	"github.com/opensourceways/image-scanning/utils"
 )
 
@@ -26,10 +33,21 @@ func LoadConfig(path string, cfg *Config, remove bool) error {
 }
 
 type Config struct {
-	Community   []domain.Community `json:"community"`
-	TrivyRepo   app.TrivyRepo      `json:"trivy_repo"`
-	Postgresql  postgresql.Config  `json:"postgresql"`
-	Concurrency app.Concurrency    `json:"concurrency"`
+	Community   []domain.Community     `json:"community"`
+	TrivyRepo   app.TrivyRepo          `json:"trivy_repo"`
+	Postgresql  postgresql.Config      `json:"postgresql"`
+	Concurrency app.Concurrency        `json:"concurrency"`
+	Kafka       kafka.Config           `json:"kafka"`
+	Message     messageimpl.Config     `json:"message"`
+	Api         controller.Config      `json:"api"`
+	Adhoc       app.AdhocConfig        `json:"adhoc"`
+	Tracing     tracing.Config         `json:"tracing"`
+	Health      app.HealthConfig       `json:"health"`
+	Command     command.Config         `json:"command"`
+	Timeout     app.Timeout            `json:"timeout"`
+	Leader      leader.Config          `json:"leader"`
+	Retry       app.Retry              `json:"retry"`
+	RateLimit   ratelimiterimpl.Config `json:"rate_limit"`
 }
 
 // ConfigItems returns a slice of interface{} containing pointers to the configuration items.
@@ -38,6 +56,17 @@ func (cfg *Config) ConfigItems() []interface{} {
 		&cfg.Community,
 		&cfg.TrivyRepo,
 		&cfg.Concurrency,
+		&cfg.Kafka,
+		&cfg.Message,
+		&cfg.Api,
+		&cfg.Adhoc,
+		&cfg.Tracing,
+		&cfg.Health,
+		&cfg.Command,
+		&cfg.Timeout,
+		&cfg.Leader,
+		&cfg.Retry,
+		&cfg.RateLimit,
 	}
 }
 
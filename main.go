@@ -1,17 +1,22 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/opensourceways/image-scanning/common/infrastructure/kafka"
 	"github.com/opensourceways/image-scanning/common/infrastructure/postgresql"
+	"github.com/opensourceways/image-scanning/common/infrastructure/tracing"
 	"github.com/opensourceways/image-scanning/config"
 	"github.com/opensourceways/image-scanning/scanning"
+	"github.com/opensourceways/image-scanning/scanning/controller"
 )
 
 const (
@@ -76,6 +81,10 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == scanCmd {
+		os.Exit(runScan(os.Args[2:]))
+	}
+
 	o := gatherOptions(
 		flag.NewFlagSet(os.Args[0], flag.ExitOnError),
 		os.Args[1:]...,
@@ -112,16 +121,39 @@ func main() {
 		return
 	}
 
-	go healthCheck()
+	// kafka
+	if err := kafka.Init(&cfg.Kafka); err != nil {
+		logrus.Errorf("init kafka failed, err:%s", err.Error())
 
-	scanning.Run(cfg)
-}
+		return
+	}
 
-func healthCheck() {
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprintf(w, "i am ok")
-	})
-	if err := http.ListenAndServe(":8080", nil); err != nil {
-		panic(err)
+	defer func() {
+		if err := kafka.Exit(); err != nil {
+			logrus.Errorf("close kafka producer failed, err:%s", err.Error())
+		}
+	}()
+
+	// tracing
+	shutdown, err := tracing.Init(&cfg.Tracing)
+	if err != nil {
+		logrus.Errorf("init tracing failed, err:%s", err.Error())
+
+		return
 	}
-}
\ No newline at end of file
+
+	defer func() {
+		if err := shutdown(context.Background()); err != nil {
+			logrus.Errorf("shutdown tracing failed, err:%s", err.Error())
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	scanning.Run(ctx, cfg, controller.ServerOptions{
+		Port: o.service.Port,
+		Cert: o.service.Cert,
+		Key:  o.service.Key,
+	}, o.service.GracePeriod)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/image-scanning/common/infrastructure/command"
+	"github.com/opensourceways/image-scanning/scanning/app"
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/infrastructure/ratelimiterimpl"
+)
+
+const (
+	scanCmd = "scan"
+
+	formatMarkdown = "markdown"
+	formatJSON     = "json"
+)
+
+type scanOptions struct {
+	image  string
+	arch   string
+	format string
+}
+
+func (o *scanOptions) addFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.image, "image", "", "Image to scan, e.g. docker.io/openeuler/openeuler:24.03-lts.")
+	fs.StringVar(&o.arch, "arch", "amd64", "Comma separated arches to scan.")
+	fs.StringVar(&o.format, "format", formatMarkdown, "Output format, markdown or json.")
+}
+
+func (o *scanOptions) validate() error {
+	if o.image == "" {
+		return fmt.Errorf("missing image")
+	}
+
+	if o.format != formatMarkdown && o.format != formatJSON {
+		return fmt.Errorf("unsupported format %s", o.format)
+	}
+
+	return nil
+}
+
+type scanOutput struct {
+	Image  string              `json:"image"`
+	Status string              `json:"status"`
+	Error  string              `json:"error,omitempty"`
+	Arches []domain.ArchRecord `json:"arches"`
+}
+
+// runScan 在当前目录下使用已经初始化的trivy环境扫描镜像，不需要数据库，也不会上传报告
+func runScan(args []string) int {
+	var o scanOptions
+
+	fs := flag.NewFlagSet(scanCmd, flag.ExitOnError)
+	o.addFlags(fs)
+	_ = fs.Parse(args)
+
+	if err := o.validate(); err != nil {
+		logrus.Errorf("Invalid options, err:%s", err.Error())
+
+		return 2
+	}
+
+	scan, err := domain.NewAdhocScan(uuid.NewString(), o.image, strings.Split(o.arch, ","))
+	if err != nil {
+		logrus.Errorf("Invalid options, err:%s", err.Error())
+
+		return 2
+	}
+
+	cmdCfg := command.Config{}
+	cmdCfg.SetDefault()
+
+	timeout := app.Timeout{}
+	timeout.SetDefault()
+
+	rateLimit := ratelimiterimpl.Config{}
+	rateLimit.SetDefault()
+
+	app.NewImageScanner(
+		command.NewRunner(&cmdCfg), &timeout, ratelimiterimpl.NewRateLimiter(&rateLimit),
+	).RunAdhocScan(context.Background(), &scan)
+
+	if o.format == formatJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(scanOutput{
+			Image:  scan.ImagePath(),
+			Status: scan.Status,
+			Error:  scan.Error,
+			Arches: scan.Arches,
+		})
+	} else {
+		_, err = fmt.Fprint(os.Stdout, scan.Report)
+	}
+
+	if err != nil {
+		logrus.Errorf("write result failed, err:%s", err.Error())
+
+		return 1
+	}
+
+	if scan.Status != domain.AdhocStatusSucceeded {
+		logrus.Errorf("scan %s failed: %s", scan.ImagePath(), scan.Error)
+
+		return 1
+	}
+
+	return 0
+}
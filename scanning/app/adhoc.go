@@ -0,0 +1,406 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+	"github.com/opensourceways/image-scanning/scanning/infrastructure/metrics"
+	"github.com/opensourceways/image-scanning/utils"
+)
+
+var (
+	ErrScanNotFound    = errors.New("scan not found")
+	ErrArchiveTooLarge = errors.New("archive is too large")
+)
+
+const (
+	// adhocLease worker每隔adhocLease/3续约一次，实例退出后其他实例在租约过期后重新领取
+	adhocLease = 5 * time.Minute
+	// adhocPollInterval 其他实例提交的扫描通过轮询领取，本实例提交的扫描会立即唤醒worker
+	adhocPollInterval = 10 * time.Second
+)
+
+type AdhocService interface {
+	Submit(reference string, arches []string) (domain.AdhocScan, error)
+	SubmitArchive(name, format string, archive io.Reader) (domain.AdhocScan, error)
+	Get(id string) (domain.AdhocScan, error)
+
+	Shutdown(ctx context.Context)
+}
+
+// NewAdhocService 启动cfg.Workers个协程处理临时扫描，与定时任务的队列互不影响，
+// 扫描保存在数据库中，任何实例都可以查询结果，排队中的扫描由各实例的worker领取
+func NewAdhocService(cfg *AdhocConfig, repo repository.AdhocScan, scanner *imageScanner) *adhocService {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &adhocService{
+		repo:            repo,
+		scanner:         scanner,
+		owner:           workerOwner(),
+		wake:            make(chan struct{}, cfg.Workers),
+		queueSize:       cfg.QueueSize,
+		retention:       cfg.retention(),
+		maxArchiveBytes: cfg.maxArchiveBytes(),
+		ctx:             ctx,
+		cancel:          cancel,
+		stop:            make(chan struct{}),
+	}
+
+	for i := 0; i < cfg.Workers; i++ {
+		s.workers.Add(1)
+		go s.work()
+	}
+
+	metrics.RegisterGaugeFunc("queue_depth", "Number of tasks waiting in the queue.",
+		prometheus.Labels{"queue": "adhoc"}, func() float64 {
+			n, err := repo.CountQueued()
+			if err != nil {
+				logrus.Errorf("count queued adhoc scans failed: %s", err.Error())
+			}
+
+			return float64(n)
+		},
+	)
+
+	return s
+}
+
+type adhocService struct {
+	repo            repository.AdhocScan
+	scanner         *imageScanner
+	queueSize       int
+	retention       time.Duration
+	maxArchiveBytes int64
+
+	// owner 标识本实例持有的租约，也是本实例保存的镜像包的Node
+	owner string
+	wake  chan struct{}
+
+	// mu 保证检查队列长度和入队之间不会有其他请求插入，正在上传的镜像包也占用队列的位置
+	mu        sync.Mutex
+	uploading int
+
+	// ctx在退出等待超时后取消，结束正在运行的扫描；stop关闭后不再领取新的扫描
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stop     chan struct{}
+	stopOnce sync.Once
+	workers  sync.WaitGroup
+}
+
+func (s *adhocService) Submit(reference string, arches []string) (domain.AdhocScan, error) {
+	scan, err := domain.NewAdhocScan(uuid.NewString(), reference, arches)
+	if err != nil {
+		return scan, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return scan, s.enqueue(&scan)
+}
+
+// enqueue 调用方需要持有锁，队列长度为所有实例中排队的扫描数
+func (s *adhocService) enqueue(scan *domain.AdhocScan) error {
+	s.evict()
+
+	if err := s.checkQueue(s.uploading); err != nil {
+		return err
+	}
+
+	if err := s.repo.Add(scan); err != nil {
+		return err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// checkQueue 调用方需要持有锁
+func (s *adhocService) checkQueue(uploading int) error {
+	queued, err := s.repo.CountQueued()
+	if err != nil {
+		return err
+	}
+
+	if int(queued)+uploading >= s.queueSize {
+		return ErrScanQueueFull
+	}
+
+	return nil
+}
+
+// SubmitArchive 先将镜像包写入磁盘，超过大小限制时丢弃
+func (s *adhocService) SubmitArchive(name, format string, archive io.Reader) (domain.AdhocScan, error) {
+	scan, err := domain.NewArchiveScan(uuid.NewString(), name, format, s.owner)
+	if err != nil {
+		return scan, err
+	}
+
+	if err = s.reserveUpload(); err != nil {
+		return scan, err
+	}
+
+	err = s.saveArchive(&scan, archive)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.uploading--
+
+	if err == nil {
+		err = s.enqueue(&scan)
+	}
+
+	if err != nil {
+		clearAdhocImage(&scan)
+	}
+
+	return scan, err
+}
+
+func (s *adhocService) reserveUpload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkQueue(s.uploading); err != nil {
+		return err
+	}
+
+	s.uploading++
+
+	return nil
+}
+
+func (s *adhocService) saveArchive(scan *domain.AdhocScan, archive io.Reader) error {
+	if err := os.MkdirAll(domain.AdhocImagesDir, 0750); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(scan.LocalImagePath(""), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0640)
+	if err != nil {
+		return err
+	}
+
+	// 多读取一个字节用于判断是否超过大小限制
+	n, err := io.Copy(f, io.LimitReader(archive, s.maxArchiveBytes+1))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil && n > s.maxArchiveBytes {
+		err = ErrArchiveTooLarge
+	}
+
+	return err
+}
+
+func (s *adhocService) Get(id string) (domain.AdhocScan, error) {
+	scan, err := s.repo.Find(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return scan, ErrScanNotFound
+	}
+
+	return scan, err
+}
+
+// evict 清理超过保留时长的扫描，实例异常退出时遗留的镜像包扫描也一并清理
+func (s *adhocService) evict() {
+	if err := s.repo.DeleteBefore(time.Now().Add(-s.retention)); err != nil {
+		logrus.Errorf("delete expired adhoc scans failed: %s", err.Error())
+	}
+}
+
+func (s *adhocService) work() {
+	defer s.workers.Done()
+
+	ticker := time.NewTicker(adhocPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+
+		if s.claimAndRun() {
+			continue
+		}
+
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claimAndRun 返回是否领取到扫描，领取到时继续领取下一个
+func (s *adhocService) claimAndRun() bool {
+	scans, err := s.repo.Claim(s.owner, s.owner, adhocLease)
+	if err != nil {
+		logrus.Errorf("claim adhoc scan failed: %s", err.Error())
+		return false
+	}
+
+	if len(scans) == 0 {
+		return false
+	}
+
+	scan := scans[0]
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+
+	stopHeartbeat := s.heartbeat(scan.Id, cancel)
+	s.scanner.RunAdhocScan(ctx, &scan)
+	stopHeartbeat()
+
+	// 退出时被取消的扫描不保存结果，租约过期后由其他实例重新执行
+	if s.ctx.Err() != nil && !scan.IsArchive() {
+		return true
+	}
+
+	if err = s.repo.Complete(&scan, s.owner); err != nil {
+		logrus.Errorf("save adhoc scan %s failed: %s", scan.Id, err.Error())
+	}
+
+	return true
+}
+
+// heartbeat 定期续约，租约丢失时扫描可能已经被其他实例领取，取消本次扫描
+func (s *adhocService) heartbeat(id string, cancel context.CancelFunc) func() {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+
+	go func() {
+		defer close(exited)
+
+		ticker := time.NewTicker(adhocLease / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			err := s.repo.RenewLease(id, s.owner, adhocLease)
+			if errors.Is(err, repository.ErrLeaseLost) {
+				logrus.Errorf("lease of adhoc scan %s lost, cancel the scan", id)
+				cancel()
+
+				return
+			}
+
+			if err != nil {
+				logrus.Errorf("renew lease of adhoc scan %s failed: %s", id, err.Error())
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-exited
+	}
+}
+
+// Shutdown 停止领取新的扫描并等待正在运行的扫描，镜像包只保存在本实例，
+// 排队中的镜像包扫描无法由其他实例执行，标记为失败；镜像地址的扫描留在队列中由其他实例领取
+func (s *adhocService) Shutdown(ctx context.Context) {
+	s.stopOnce.Do(func() { close(s.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logrus.Warn("grace period exceeded, cancel the running adhoc scans")
+		s.cancel()
+
+		select {
+		case <-done:
+		case <-time.After(cancelTimeout):
+			logrus.Error("running adhoc scans are not finished after being canceled")
+		}
+	}
+
+	scans, err := s.repo.FailQueued(s.owner, "the instance that received the archive has shut down")
+	if err != nil {
+		logrus.Errorf("fail queued archive scans failed: %s", err.Error())
+	}
+
+	for i := range scans {
+		clearAdhocImage(&scans[i])
+	}
+}
+
+// RunAdhocScan 同步执行临时扫描，扫描结束后删除下载的镜像
+func (s *imageScanner) RunAdhocScan(ctx context.Context, scan *domain.AdhocScan) {
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.Errorf("adhoc scan %s panic %v", scan.ImagePath(), r)
+			scan.Finish(nil, errors.New("scan panic"))
+		}
+	}()
+
+	ctx, span := tracer.Start(ctx, "AdhocScan", trace.WithAttributes(
+		attribute.String("scan.id", scan.Id),
+		attribute.String("image", scan.ImagePath()),
+	))
+
+	var err error
+	defer func() { utils.EndSpan(span, err) }()
+
+	scan.Start()
+	defer clearAdhocImage(scan)
+
+	if err = os.MkdirAll(domain.AdhocImagesDir, 0750); err != nil {
+		scan.Finish(nil, err)
+		return
+	}
+
+	// 上传的镜像包已经在本地，不需要下载
+	if !scan.IsArchive() {
+		if _, err = s.downloadImage(ctx, scan); err != nil {
+			scan.Finish(nil, err)
+			return
+		}
+	}
+
+	ars := s.scanImage(ctx, scan, nil)
+	err = scanError(ars)
+	scan.Finish(ars, err)
+}
+
+func clearAdhocImage(scan *domain.AdhocScan) {
+	for _, arch := range scan.FormatArch() {
+		localPath := scan.LocalImagePath(arch)
+		if err := os.RemoveAll(localPath); err != nil {
+			logrus.Errorf("remove local image %s failed: %s", localPath, err.Error())
+		}
+	}
+}
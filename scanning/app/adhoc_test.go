@@ -0,0 +1,151 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opensourceways/image-scanning/common/infrastructure/command"
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+	"github.com/opensourceways/image-scanning/scanning/infrastructure/fakeimpl"
+	"github.com/opensourceways/image-scanning/scanning/infrastructure/ratelimiterimpl"
+)
+
+const testImage = "quay.io/openeuler/openeuler:24.03"
+
+// newTestRunner 在临时目录中运行，skopeo和trivy由fakeimpl模拟
+func newTestRunner(t *testing.T) fakeRunner {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(t.TempDir())
+
+	return fakeimpl.NewRunner(filepath.Join(wd, "../infrastructure/fakeimpl/testdata"))
+}
+
+// fakeRunner fakeimpl的runner可以模拟命令失败
+type fakeRunner interface {
+	command.Runner
+	Fail(keyword string, err error)
+	Calls() []command.Cmd
+}
+
+func newTestScanner(t *testing.T) *imageScanner {
+	return newTestScannerWith(t, newTestRunner(t))
+}
+
+func newTestScannerWith(t *testing.T, runner command.Runner) *imageScanner {
+	timeout := Timeout{}
+	timeout.SetDefault()
+
+	rateLimit := ratelimiterimpl.Config{}
+	rateLimit.SetDefault()
+
+	return NewImageScanner(runner, &timeout, ratelimiterimpl.NewRateLimiter(&rateLimit))
+}
+
+// newTestAdhocService 多个实例共享同一个repo，模拟多副本
+func newTestAdhocService(t *testing.T, repo repository.AdhocScan, scanner *imageScanner, workers int) *adhocService {
+	s := NewAdhocService(&AdhocConfig{QueueSize: 2, Workers: workers, Retention: "1d", MaxArchiveSize: 1}, repo, scanner)
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+
+	return s
+}
+
+func waitFinished(t *testing.T, s *adhocService, id string) domain.AdhocScan {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		scan, err := s.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if scan.IsFinished() {
+			return scan
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("adhoc scan %s is not finished", id)
+
+	return domain.AdhocScan{}
+}
+
+func TestAdhocScanSharedBetweenInstances(t *testing.T) {
+	scanner := newTestScanner(t)
+	repo := fakeimpl.NewAdhocScanImpl()
+
+	// 接收请求的实例没有worker，扫描只能由另一个实例执行
+	api := newTestAdhocService(t, repo, scanner, 0)
+
+	scan, err := api.Submit(testImage, []string{"amd64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newTestAdhocService(t, repo, scanner, 1)
+
+	scan = waitFinished(t, api, scan.Id)
+	if scan.Status != domain.AdhocStatusSucceeded || len(scan.Arches) != 1 || scan.Report == "" {
+		t.Errorf("unexpected result: %+v", scan)
+	}
+
+	if _, err = api.Get("no-such-scan"); !errors.Is(err, ErrScanNotFound) {
+		t.Errorf("want ErrScanNotFound, got %v", err)
+	}
+}
+
+func TestAdhocQueueIsShared(t *testing.T) {
+	scanner := newTestScanner(t)
+	repo := fakeimpl.NewAdhocScanImpl()
+
+	a := newTestAdhocService(t, repo, scanner, 0)
+	b := newTestAdhocService(t, repo, scanner, 0)
+
+	for _, s := range []*adhocService{a, b} {
+		if _, err := s.Submit(testImage, []string{"amd64"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := a.Submit(testImage, []string{"arm64"}); !errors.Is(err, ErrScanQueueFull) {
+		t.Errorf("want ErrScanQueueFull, got %v", err)
+	}
+}
+
+func TestAdhocArchiveStaysOnReceivingInstance(t *testing.T) {
+	newTestScanner(t)
+	repo := fakeimpl.NewAdhocScanImpl()
+
+	api := NewAdhocService(&AdhocConfig{QueueSize: 2, Retention: "1d", MaxArchiveSize: 1}, repo, nil)
+
+	scan, err := api.SubmitArchive("app", domain.ArchiveFormatDocker, strings.NewReader("archive"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if scans, _ := repo.Claim("other", "other", time.Minute); len(scans) != 0 {
+		t.Fatalf("archive scan claimed by another instance: %+v", scans)
+	}
+
+	// 退出时无法交给其他实例，标记为失败并删除镜像包
+	api.Shutdown(context.Background())
+
+	if scan, err = api.Get(scan.Id); err != nil || scan.Status != domain.AdhocStatusFailed {
+		t.Errorf("want failed scan, got %+v, %v", scan, err)
+	}
+
+	if _, err = os.Stat(scan.LocalImagePath("")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("archive should be removed: %v", err)
+	}
+}
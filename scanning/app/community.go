@@ -1,41 +1,119 @@
 package app
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"sync"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 
 	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/message"
+	"github.com/opensourceways/image-scanning/scanning/domain/notifier"
 	"github.com/opensourceways/image-scanning/scanning/domain/platform"
 	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+	"github.com/opensourceways/image-scanning/scanning/infrastructure/metrics"
 	"github.com/opensourceways/image-scanning/utils"
 )
 
-const (
-	trivyCmd = trivyResourceDir + "trivy/trivy"
-	skopeo   = "skopeo"
-)
+// communityConfig 从扫描配置得到的社区设置，配置变化时整体替换，一次扫描始终使用同一份
+type communityConfig struct {
+	sha         string
+	platform    platform.Platform
+	policy      domain.Policy
+	issueConfig domain.IssueConfig
+	ownerLabels domain.OwnerLabels
+	vex         domain.VexStatements
+	scanTimes   *domain.ScanTimes
+}
 
-func newCommunityHandler(c domain.Community, repo repository.Task, p platform.Platform) *communityHandler {
+func newCommunityHandler(
+	c domain.Community, repos Repositories, n notifier.Notifier, pub message.Publisher, scanner *imageScanner,
+) *communityHandler {
 	return &communityHandler{
-		name:     c.Name,
-		repo:     repo,
-		platform: p,
+		name:             c.Name,
+		scanner:          scanner,
+		repo:             repos.Task,
+		recordRepo:       repos.Record,
+		issueRepo:        repos.Issue,
+		findingRepo:      repos.Finding,
+		config:           &communityConfig{},
+		notifier:         n,
+		publisher:        pub,
+		failureThreshold: c.Notification.GetFailureThreshold(),
+		scheduling:       c.Scheduling,
 	}
 }
 
 type communityHandler struct {
-	name     string
-	repo     repository.Task
-	platform platform.Platform
+	name        string
+	repo        repository.Task
+	recordRepo  repository.ScanRecord
+	issueRepo   repository.Issue
+	findingRepo repository.Finding
+	scanner     *imageScanner
+
+	publisher message.Publisher
+
+	notifier         notifier.Notifier
+	failureThreshold int
+
+	scheduling domain.Scheduling
+
+	configLock sync.RWMutex
+	config     *communityConfig
+
+	// syncedSha 最近一次同步任务时配置文件的sha，只在GenerateTask中访问
+	syncedSha string
+}
+
+func (h *communityHandler) getConfig() *communityConfig {
+	h.configLock.RLock()
+	defer h.configLock.RUnlock()
+
+	return h.config
+}
+
+func (h *communityHandler) setConfig(cfg *communityConfig) {
+	h.configLock.Lock()
+	defer h.configLock.Unlock()
+
+	h.config = cfg
+}
+
+// setVex VEX文档独立于扫描配置更新，只替换其中的VEX
+func (h *communityHandler) setVex(vex domain.VexStatements) {
+	h.configLock.Lock()
+	defer h.configLock.Unlock()
+
+	cfg := *h.config
+	cfg.vex = vex
+	h.config = &cfg
+}
+
+func newCommunityConfig(
+	plat platform.Platform, sha string, scanConfig *domain.ScanConfig, vex domain.VexStatements,
+	scanTimes *domain.ScanTimes,
+) *communityConfig {
+	return &communityConfig{
+		sha:         sha,
+		platform:    plat,
+		policy:      scanConfig.Policy,
+		issueConfig: scanConfig.Issue,
+		ownerLabels: scanConfig.Scanner.Global.OwnerLabels,
+		vex:         vex,
+		scanTimes:   scanTimes,
+	}
 }
 
-func (h *communityHandler) generateTask(scanConfig domain.ScanConfig) {
-	taskSets := domain.GenerateTask(h.name, &scanConfig)
+// syncTasks 根据扫描配置增删任务，多副本时只由leader执行
+func (h *communityHandler) syncTasks(ctx context.Context, scanConfig *domain.ScanConfig) {
+	taskSets := domain.GenerateTask(ctx, h.name, scanConfig, h.scanner.limiter)
 	if err := h.clearOldTasks(taskSets); err != nil {
 		logrus.Errorf("clear old task of %s failed: %s", h.name, err.Error())
 	}
@@ -56,11 +134,15 @@ func (h *communityHandler) clearOldTasks(newTasks map[string]domain.Task) error
 	}
 
 	var clearIds []int64
+	var clearTasks []domain.Task
+	var clearImages []string
 	for _, oldTask := range oldTasks {
 		_, ok := newTasks[oldTask.UniqueKey()]
 		if !ok {
 			h.clearLocalImageFile(&oldTask)
 			clearIds = append(clearIds, oldTask.Id)
+			clearTasks = append(clearTasks, oldTask)
+			clearImages = append(clearImages, oldTask.ImagePath())
 		}
 	}
 
@@ -68,7 +150,21 @@ func (h *communityHandler) clearOldTasks(newTasks map[string]domain.Task) error
 		return nil
 	}
 
-	return h.repo.DeleteByIds(clearIds)
+	if err = h.repo.DeleteByIds(clearIds); err != nil {
+		return err
+	}
+
+	if err = h.findingRepo.DeleteByTaskIds(clearIds); err != nil {
+		logrus.Errorf("delete findings of %s failed: %s", h.name, err.Error())
+	}
+
+	for i := range clearTasks {
+		publish(h.publisher, domain.NewTaskDeletedEvent(&clearTasks[i]))
+	}
+
+	h.closeIssuesOfImages(clearImages)
+
+	return nil
 }
 
 func (h *communityHandler) clearLocalImageFile(task *domain.Task) {
@@ -81,78 +177,136 @@ func (h *communityHandler) clearLocalImageFile(task *domain.Task) {
 }
 
 func (h *communityHandler) saveTask(newTask domain.Task) error {
+	created, rescheduled := false, false
 	oldTask, err := h.repo.Find(newTask)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			oldTask = newTask
+			created = true
 		} else {
 			return err
 		}
 	} else {
-		oldTask.UpdateIntervalAndArch(newTask.Interval, newTask.Arch)
+		rescheduled = oldTask.UpdateScheduleAndArch(newTask.Schedule(), newTask.Arch)
+		oldTask.UpdatePriority(newTask.Priority)
+		oldTask.UpdateOwner(newTask.Owner)
 	}
 
-	return h.repo.Save(oldTask)
-}
-
-func (h *communityHandler) handleTask(task *domain.Task) error {
-	if err := h.downloadImage(task); err != nil {
+	if err = h.repo.Save(oldTask); err != nil {
 		return err
 	}
 
-	ars := make(map[string]domain.ArchResult, len(task.Arch))
-	for _, arch := range task.FormatArch() {
-		param := []string{
-			"image",
-			"--quiet",
-			"--skip-db-update",
-			"-f", "json",
-			"--scanners", "vuln",
-			"--cache-dir", trivyResourceDir,
-			"--input",
-			task.LocalImagePath(arch),
+	if rescheduled {
+		if err = h.repo.Reschedule(oldTask.Id, oldTask.NextScanTime); err != nil {
+			return err
+		}
+	}
+
+	if created {
+		// 新建任务的id在保存后才生成，重新查询一次
+		if task, err := h.repo.Find(newTask); err == nil {
+			oldTask = task
 		}
 
-		ars[arch] = h.handleArch(param)
+		publish(h.publisher, domain.NewTaskCreatedEvent(&oldTask))
 	}
 
-	return h.platform.Upload(domain.BuildContent(ars), task.MarkdownPath())
+	return nil
 }
 
-func (h *communityHandler) downloadImage(task *domain.Task) error {
-	for _, arch := range task.FormatArch() {
-		exist, err := utils.PathExists(task.LocalImagePath(arch))
-		if err != nil {
-			return err
-		}
+// scanProgress 记录扫描进入的阶段和拉取的镜像大小
+type scanProgress interface {
+	enterStage(status string)
+	addBytesPulled(n int64)
+}
 
-		if exist {
-			continue
-		}
+// handleTask 返回本次扫描的结果，下载镜像失败时结果为空
+func (h *communityHandler) handleTask(
+	ctx context.Context, task *domain.Task, policy *domain.RetryPolicy, progress scanProgress,
+) (*domain.ScanRecord, error) {
+	done := metrics.ScanStarted(h.name)
 
-		out, err := utils.RunCmd(skopeo,
-			"copy",
-			"--override-arch", arch,
-			fmt.Sprintf("docker://%s", task.ImagePath()),
-			fmt.Sprintf("oci:./%s", task.LocalImagePath(arch)),
-		)
-		if err != nil {
-			logrus.Errorf("download image %s failed: out: %s, err:%s", task.ImagePath(), out, err.Error())
-			return err
-		}
+	record, err := h.scanTask(ctx, task, progress)
+
+	// 被中断的扫描会重新执行，不计入失败
+	if ctx.Err() == nil {
+		h.trackFailure(task, err, policy)
 	}
 
-	return nil
+	done(err == nil)
+
+	return record, err
 }
 
-func (h *communityHandler) handleArch(param []string) domain.ArchResult {
-	var ar domain.ArchResult
-	out, err := utils.RunCmd(trivyCmd, param...)
+func (h *communityHandler) scanTask(
+	ctx context.Context, task *domain.Task, progress scanProgress,
+) (*domain.ScanRecord, error) {
+	progress.enterStage(domain.TaskStatusPulling)
+	pulled, err := h.scanner.downloadImage(ctx, task)
+	progress.addBytesPulled(pulled)
 	if err != nil {
-		ar.Err = err
-	} else {
-		ar.Err = json.Unmarshal([]byte(out), &ar.ScanResult)
+		metrics.IncFailure(h.name, metrics.StagePull)
+		return nil, err
+	}
+
+	cfg := h.getConfig()
+
+	progress.enterStage(domain.TaskStatusScanning)
+	ars := h.scanner.scanImage(ctx, task, cfg.vex)
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	return ar
+	scanErr := scanError(ars)
+	if scanErr != nil {
+		metrics.IncFailure(h.name, metrics.StageScan)
+	}
+
+	owner := domain.ResolveOwner(task, ars, cfg.ownerLabels)
+	pr := cfg.policy.Evaluate(ars)
+
+	record := domain.NewScanRecord(task, ars, owner, pr)
+	previous, err := h.recordRepo.FindLatest(task.Id)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logrus.Errorf("find latest scan record of %s failed: %s", task.UniqueKey(), err.Error())
+	}
+
+	if err = h.recordRepo.Save(&record); err != nil {
+		logrus.Errorf("save scan record of %s failed: %s", task.UniqueKey(), err.Error())
+	} else if err = h.findingRepo.Replace(task.Id, record.FailedArches(), record.Findings()); err != nil {
+		logrus.Errorf("save findings of %s failed: %s", task.UniqueKey(), err.Error())
+	}
+
+	// 首次扫描的结果作为基线，不发送通知
+	if previous.Id != 0 {
+		h.notifyNewFindings(&record, &previous)
+	}
+
+	h.handleIssue(cfg, &record)
+
+	progress.enterStage(domain.TaskStatusUploading)
+	err = upload(ctx, cfg.platform, domain.BuildContent(ars, owner, pr), task.MarkdownPath())
+	if err != nil {
+		metrics.IncFailure(h.name, metrics.StageUpload)
+	}
+
+	return &record, errors.Join(err, scanErr)
+}
+
+func scanError(ars map[string]domain.ArchResult) error {
+	var errs []error
+	for arch, ar := range ars {
+		if ar.Err != nil {
+			errs = append(errs, fmt.Errorf("scan arch %s failed: %w", arch, ar.Err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func upload(ctx context.Context, plat platform.Platform, content, path string) (err error) {
+	_, span := tracer.Start(ctx, "Platform.Upload", trace.WithAttributes(attribute.String("path", path)))
+	defer func() { utils.EndSpan(span, err) }()
+
+	return plat.Upload(content, path)
 }
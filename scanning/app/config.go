@@ -1,5 +1,12 @@
 package app
 
+import (
+	"time"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/utils"
+)
+
 type TrivyRepo struct {
 	Trivy    string `json:"trivy"     required:"true"`
 	TrivyDB  string `json:"trivy_db"  required:"true"`
@@ -20,12 +27,131 @@ func (t *TrivyRepo) SetDefault() {
 	}
 }
 
+// Concurrency Num为worker数量，Lease为领取任务的租约时长，worker在租约过期前续约，格式同StringToInterval
 type Concurrency struct {
-	Num int `json:"num" required:"true"`
+	Num   int    `json:"num" required:"true"`
+	Lease string `json:"lease"`
 }
 
 func (c *Concurrency) SetDefault() {
 	if c.Num == 0 {
 		c.Num = 10
 	}
+
+	if c.Lease == "" {
+		c.Lease = "5m"
+	}
+}
+
+func (c *Concurrency) Validate() error {
+	return utils.ValidateDurations(c.Lease)
+}
+
+func (c *Concurrency) lease() time.Duration {
+	return utils.StringToDuration(c.Lease)
+}
+
+// AdhocConfig 临时扫描使用独立的队列，QueueSize为所有实例共享的队列长度，队列满时拒绝新的请求，
+// Workers为每个实例的worker数量，扫描和结果在数据库中保留Retention时长，
+// MaxArchiveSize为上传镜像包的大小上限，单位MB
+type AdhocConfig struct {
+	QueueSize      int    `json:"queue_size"`
+	Workers        int    `json:"workers"`
+	Retention      string `json:"retention"`
+	MaxArchiveSize int64  `json:"max_archive_size"`
+}
+
+func (c *AdhocConfig) SetDefault() {
+	if c.QueueSize <= 0 {
+		c.QueueSize = 20
+	}
+
+	if c.Workers <= 0 {
+		c.Workers = 2
+	}
+
+	if c.Retention == "" {
+		c.Retention = "1d"
+	}
+
+	if c.MaxArchiveSize <= 0 {
+		c.MaxArchiveSize = 2048
+	}
+}
+
+func (c *AdhocConfig) Validate() error {
+	return utils.ValidateDurations(c.Retention)
+}
+
+func (c *AdhocConfig) maxArchiveBytes() int64 {
+	return c.MaxArchiveSize << 20
+}
+
+func (c *AdhocConfig) retention() time.Duration {
+	return utils.StringToDuration(c.Retention)
+}
+
+// Timeout 各阶段外部命令的超时时间，超时后结束整个进程组，格式同StringToInterval
+type Timeout struct {
+	Pull        string `json:"pull"`
+	Scan        string `json:"scan"`
+	TrivyInit   string `json:"trivy_init"`
+	TrivyUpdate string `json:"trivy_update"`
+}
+
+func (t *Timeout) SetDefault() {
+	if t.Pull == "" {
+		t.Pull = "30m"
+	}
+
+	if t.Scan == "" {
+		t.Scan = "30m"
+	}
+
+	// 初始化需要克隆并编译trivy和trivy-db
+	if t.TrivyInit == "" {
+		t.TrivyInit = "3h"
+	}
+
+	if t.TrivyUpdate == "" {
+		t.TrivyUpdate = "2h"
+	}
+}
+
+func (t *Timeout) Validate() error {
+	return utils.ValidateDurations(t.Pull, t.Scan, t.TrivyInit, t.TrivyUpdate)
+}
+
+// Retry 扫描失败后的重试策略，Backoff为首次重试的等待时间，之后每次失败翻倍，不超过MaxBackoff和扫描间隔，
+// 连续失败QuarantineAfter次后隔离任务，时长格式同StringToInterval
+type Retry struct {
+	Backoff         string `json:"backoff"`
+	MaxBackoff      string `json:"max_backoff"`
+	QuarantineAfter int    `json:"quarantine_after"`
+}
+
+func (r *Retry) SetDefault() {
+	if r.Backoff == "" {
+		r.Backoff = "10m"
+	}
+
+	if r.MaxBackoff == "" {
+		r.MaxBackoff = "1d"
+	}
+
+	if r.QuarantineAfter <= 0 {
+		r.QuarantineAfter = 10
+	}
+}
+
+func (r *Retry) Validate() error {
+	return utils.ValidateDurations(r.Backoff, r.MaxBackoff)
+}
+
+func (r *Retry) policy() domain.RetryPolicy {
+	return domain.RetryPolicy{
+		Backoff:         utils.StringToDuration(r.Backoff),
+		MaxBackoff:      utils.StringToDuration(r.MaxBackoff),
+		QuarantineAfter: r.QuarantineAfter,
+	}
 }
@@ -0,0 +1,59 @@
+package app
+
+import "testing"
+
+func TestConcurrencyValidateLease(t *testing.T) {
+	for s, valid := range map[string]bool{"5m": true, "1h30m": true, "0m": false, "0h": false, "0d0m": false, "x": false} {
+		c := Concurrency{Num: 1, Lease: s}
+		if err := c.Validate(); (err == nil) != valid {
+			t.Errorf("Validate(%q) = %v, want valid %v", s, err, valid)
+		}
+	}
+}
+
+type validator interface {
+	Validate() error
+}
+
+// TestDurationConfigValidate 时长为0时ticker会panic，超时会立即触发，加载配置时拒绝
+func TestDurationConfigValidate(t *testing.T) {
+	timeout := func(scan string) validator {
+		cfg := Timeout{Scan: scan}
+		cfg.SetDefault()
+
+		return &cfg
+	}
+
+	retry := func(backoff string) validator {
+		cfg := Retry{Backoff: backoff}
+		cfg.SetDefault()
+
+		return &cfg
+	}
+
+	adhoc := func(retention string) validator {
+		cfg := AdhocConfig{Retention: retention}
+		cfg.SetDefault()
+
+		return &cfg
+	}
+
+	health := func(delay string) validator {
+		cfg := HealthConfig{ExecTaskMaxDelay: delay}
+		cfg.SetDefault()
+
+		return &cfg
+	}
+
+	for name, newConfig := range map[string]func(string) validator{
+		"timeout": timeout, "retry": retry, "adhoc": adhoc, "health": health,
+	} {
+		if err := newConfig("10m").Validate(); err != nil {
+			t.Errorf("%s: 10m should be valid: %v", name, err)
+		}
+
+		if err := newConfig("0m").Validate(); err == nil {
+			t.Errorf("%s: 0m should be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+	"github.com/opensourceways/image-scanning/scanning/infrastructure/notifierimpl"
+)
+
+type DigestService interface {
+	SendDailyDigest()
+	SendWeeklyDigest()
+}
+
+func NewDigestService(cs []domain.Community, recordRepo repository.ScanRecord) *digestService {
+	return &digestService{
+		communities: cs,
+		recordRepo:  recordRepo,
+	}
+}
+
+type digestService struct {
+	communities []domain.Community
+	recordRepo  repository.ScanRecord
+}
+
+func (d *digestService) SendDailyDigest() {
+	d.send(domain.DigestPeriodDaily)
+}
+
+func (d *digestService) SendWeeklyDigest() {
+	d.send(domain.DigestPeriodWeekly)
+}
+
+func (d *digestService) send(period string) {
+	until := time.Now()
+
+	for _, c := range d.communities {
+		email := c.Notification.Email
+		if email == nil || email.GetPeriod() != period {
+			continue
+		}
+
+		digest, err := d.buildDigest(c.Name, until.Add(-email.Duration()), until)
+		if err != nil {
+			logrus.Errorf("build %s digest of %s failed: %s", period, c.Name, err.Error())
+			continue
+		}
+
+		if err = notifierimpl.NewEmailSender(email).SendDigest(&digest); err != nil {
+			logrus.Errorf("send %s digest of %s failed: %s", period, c.Name, err.Error())
+		}
+	}
+}
+
+func (d *digestService) buildDigest(community string, since, until time.Time) (domain.Digest, error) {
+	current, err := d.recordRepo.FindLatestPerTask(community, since, until)
+	if err != nil {
+		return domain.Digest{}, err
+	}
+
+	baseline, err := d.recordRepo.FindLatestPerTask(community, time.Time{}, since)
+	if err != nil {
+		return domain.Digest{}, err
+	}
+
+	return domain.NewDigest(community, since, until, current, baseline), nil
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"sort"
+)
+
+// fairQueue 社区之间的加权公平队列，社区每领取一个任务虚拟时间增加1/weight，
+// 优先从虚拟时间最小的社区领取。只记录在本实例内，多副本时各自近似公平；调用方需要持有claimLock
+type fairQueue struct {
+	vtime map[string]float64
+}
+
+func newFairQueue() *fairQueue {
+	return &fairQueue{vtime: make(map[string]float64)}
+}
+
+// order 按虚拟时间从小到大排列，相同时按名称保证顺序稳定；
+// 新加入的社区从当前最小的虚拟时间开始，避免它独占worker直到追上其他社区
+func (q *fairQueue) order(names []string) []string {
+	floor := q.minVtime()
+	for _, name := range names {
+		if _, exist := q.vtime[name]; !exist {
+			q.vtime[name] = floor
+		}
+	}
+
+	ordered := append([]string(nil), names...)
+	sort.Slice(ordered, func(i, j int) bool {
+		a, b := q.vtime[ordered[i]], q.vtime[ordered[j]]
+		if a != b {
+			return a < b
+		}
+
+		return ordered[i] < ordered[j]
+	})
+
+	return ordered
+}
+
+func (q *fairQueue) minVtime() float64 {
+	first := true
+	var floor float64
+	for _, v := range q.vtime {
+		if first || v < floor {
+			floor = v
+			first = false
+		}
+	}
+
+	return floor
+}
+
+// charge 记录name领取了一个任务，idle为本次没有到期任务的社区，它们的虚拟时间追到name开始时的位置，
+// 空闲的社区不积累额度，有了任务后不会长时间独占worker
+func (q *fairQueue) charge(name string, weight int, idle []string) {
+	start := q.vtime[name]
+	for _, n := range idle {
+		q.vtime[n] = max(q.vtime[n], start)
+	}
+
+	q.vtime[name] = start + 1/float64(weight)
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+)
+
+var (
+	ErrEmptyFindingQuery = errors.New("at least one of cve, package and severity is required")
+	ErrVersionNoPackage  = errors.New("version range requires package")
+)
+
+// FindingQuery Version为软件包安装版本的范围，只有指定了Package时才能使用
+type FindingQuery struct {
+	Community  string
+	Cve        string
+	Package    string
+	Version    string
+	Severities []string
+	Fixable    *bool
+	Page       int
+	Size       int
+}
+
+type FindingService interface {
+	Search(q *FindingQuery) ([]domain.Finding, int64, error)
+	Backfill()
+}
+
+func NewFindingService(
+	cs []domain.Community, findingRepo repository.Finding, recordRepo repository.ScanRecord,
+) *findingService {
+	return &findingService{
+		communities: cs,
+		findingRepo: findingRepo,
+		recordRepo:  recordRepo,
+	}
+}
+
+type findingService struct {
+	communities []domain.Community
+	findingRepo repository.Finding
+	recordRepo  repository.ScanRecord
+}
+
+func (s *findingService) Search(q *FindingQuery) ([]domain.Finding, int64, error) {
+	if q.Cve == "" && q.Package == "" && len(q.Severities) == 0 {
+		return nil, 0, ErrEmptyFindingQuery
+	}
+
+	opt := repository.FindingListOption{
+		Community:       q.Community,
+		VulnerabilityID: q.Cve,
+		PkgName:         q.Package,
+		Severities:      q.Severities,
+		Fixable:         q.Fixable,
+		Page:            q.Page,
+		Size:            q.Size,
+	}
+
+	if q.Version == "" {
+		return s.findingRepo.List(&opt)
+	}
+
+	if q.Package == "" {
+		return nil, 0, ErrVersionNoPackage
+	}
+
+	versionRange, err := domain.ParseVersionRange(q.Version)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 版本无法在数据库中比较，查询软件包的全部结果后过滤再分页
+	opt.Page, opt.Size = 0, 0
+	all, _, err := s.findingRepo.List(&opt)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var matched []domain.Finding
+	for i := range all {
+		if versionRange.Contains(all[i].InstalledVersion) {
+			matched = append(matched, all[i])
+		}
+	}
+
+	return paginate(matched, q.Page, q.Size), int64(len(matched)), nil
+}
+
+func paginate[T any](items []T, page, size int) []T {
+	if size <= 0 {
+		return items
+	}
+
+	start := (max(page, 1) - 1) * size
+	if start >= len(items) {
+		return nil
+	}
+
+	return items[start:min(start+size, len(items))]
+}
+
+// Backfill 首次启用时使用每个任务最新的扫描记录初始化，之后随每次扫描更新
+func (s *findingService) Backfill() {
+	empty, err := s.findingRepo.IsEmpty()
+	if err != nil {
+		logrus.Errorf("check findings failed: %s", err.Error())
+		return
+	}
+
+	if !empty {
+		return
+	}
+
+	for _, c := range s.communities {
+		records, err := s.recordRepo.FindLatestPerTask(c.Name, time.Time{}, time.Now())
+		if err != nil {
+			logrus.Errorf("find latest scan records of %s failed: %s", c.Name, err.Error())
+			continue
+		}
+
+		for i := range records {
+			r := &records[i]
+			if err = s.findingRepo.Replace(r.TaskId, r.FailedArches(), r.Findings()); err != nil {
+				logrus.Errorf("backfill findings of %s failed: %s", r.Image, err.Error())
+			}
+		}
+	}
+}
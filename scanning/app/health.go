@@ -0,0 +1,236 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/opensourceways/image-scanning/utils"
+)
+
+const (
+	JobGenerateTask = "GenerateTask"
+	JobExecTask     = "ExecTask"
+
+	HealthOK   = "ok"
+	HealthFail = "fail"
+
+	persistentDir      = "persistent"
+	healthCheckTimeout = 3 * time.Second
+)
+
+// HealthConfig MinFreeDisk单位MB，其余为StringToInterval格式的时长
+type HealthConfig struct {
+	MinFreeDisk          uint64 `json:"min_free_disk"`
+	TrivyDBMaxAge        string `json:"trivy_db_max_age"`
+	ExecTaskMaxDelay     string `json:"exec_task_max_delay"`
+	GenerateTaskMaxDelay string `json:"generate_task_max_delay"`
+}
+
+func (c *HealthConfig) SetDefault() {
+	if c.MinFreeDisk == 0 {
+		c.MinFreeDisk = 1024
+	}
+
+	// trivy db每6小时更新一次，允许连续几次失败
+	if c.TrivyDBMaxAge == "" {
+		c.TrivyDBMaxAge = "1d"
+	}
+
+	if c.ExecTaskMaxDelay == "" {
+		c.ExecTaskMaxDelay = "10m"
+	}
+
+	if c.GenerateTaskMaxDelay == "" {
+		c.GenerateTaskMaxDelay = "2h"
+	}
+}
+
+func (c *HealthConfig) Validate() error {
+	return utils.ValidateDurations(c.TrivyDBMaxAge, c.ExecTaskMaxDelay, c.GenerateTaskMaxDelay)
+}
+
+type HealthCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+type HealthReport struct {
+	Status string        `json:"status"`
+	Checks []HealthCheck `json:"checks"`
+}
+
+func (r *HealthReport) Healthy() bool {
+	return r.Status == HealthOK
+}
+
+// HealthService 存活检查只关注定时任务是否卡住，就绪检查还包括数据库、trivy db和磁盘空间；
+// 启动初始化完成之前存活检查通过，就绪检查不通过
+type HealthService interface {
+	Liveness(ctx context.Context) HealthReport
+	Readiness(ctx context.Context) HealthReport
+	Track(job string, run func() error) func() error
+	MarkInitialized()
+}
+
+func NewHealthService(cfg *HealthConfig, ping func(context.Context) error) *healthService {
+	return &healthService{
+		cfg:     cfg,
+		ping:    ping,
+		started: time.Now(),
+		lastRun: make(map[string]time.Time),
+		maxDelay: map[string]time.Duration{
+			JobExecTask:     utils.StringToDuration(cfg.ExecTaskMaxDelay),
+			JobGenerateTask: utils.StringToDuration(cfg.GenerateTaskMaxDelay),
+		},
+	}
+}
+
+type healthService struct {
+	cfg      *HealthConfig
+	ping     func(context.Context) error
+	maxDelay map[string]time.Duration
+
+	mu          sync.Mutex
+	started     time.Time
+	initialized bool
+	lastRun     map[string]time.Time
+}
+
+// MarkInitialized trivy初始化和首次生成任务可能耗时很久，定时任务的延迟从初始化完成时开始计算
+func (h *healthService) MarkInitialized() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.initialized = true
+	h.started = time.Now()
+}
+
+// Track 记录定时任务最近一次开始运行的时间
+func (h *healthService) Track(job string, run func() error) func() error {
+	return func() error {
+		h.mu.Lock()
+		h.lastRun[job] = time.Now()
+		h.mu.Unlock()
+
+		return run()
+	}
+}
+
+func (h *healthService) Liveness(ctx context.Context) HealthReport {
+	return newHealthReport(h.checkJobs())
+}
+
+func (h *healthService) Readiness(ctx context.Context) HealthReport {
+	checks := []HealthCheck{
+		h.checkInit(),
+		h.checkDB(ctx),
+		h.checkTrivyDB(),
+		h.checkDisk(),
+	}
+
+	return newHealthReport(append(checks, h.checkJobs()...))
+}
+
+func newHealthReport(checks []HealthCheck) HealthReport {
+	r := HealthReport{Status: HealthOK, Checks: checks}
+	for i := range checks {
+		if checks[i].Status != HealthOK {
+			r.Status = HealthFail
+		}
+	}
+
+	return r
+}
+
+func newHealthCheck(name string, err error) HealthCheck {
+	if err != nil {
+		return HealthCheck{Name: name, Status: HealthFail, Message: err.Error()}
+	}
+
+	return HealthCheck{Name: name, Status: HealthOK}
+}
+
+func healthyCheck(name, format string, a ...interface{}) HealthCheck {
+	return HealthCheck{Name: name, Status: HealthOK, Message: fmt.Sprintf(format, a...)}
+}
+
+func (h *healthService) checkInit() HealthCheck {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.initialized {
+		return newHealthCheck("init", errors.New("trivy init and task generation are not finished"))
+	}
+
+	return newHealthCheck("init", nil)
+}
+
+func (h *healthService) checkDB(ctx context.Context) HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	return newHealthCheck("db", h.ping(ctx))
+}
+
+func (h *healthService) checkTrivyDB() HealthCheck {
+	updatedAt, err := dbUpdatedAt()
+	if err != nil {
+		return newHealthCheck("trivy_db", err)
+	}
+
+	age := time.Since(updatedAt)
+	if age > utils.StringToDuration(h.cfg.TrivyDBMaxAge) {
+		return newHealthCheck("trivy_db", fmt.Errorf("trivy db is %s old", age.Round(time.Minute)))
+	}
+
+	return healthyCheck("trivy_db", "updated at %s", updatedAt.Format(time.RFC3339))
+}
+
+func (h *healthService) checkDisk() HealthCheck {
+	free, err := utils.FreeDiskSpace(persistentDir)
+	if err != nil {
+		return newHealthCheck("disk", err)
+	}
+
+	freeMB := free >> 20
+	if freeMB < h.cfg.MinFreeDisk {
+		return newHealthCheck("disk", fmt.Errorf("only %d MB free in %s", freeMB, persistentDir))
+	}
+
+	return healthyCheck("disk", "%d MB free", freeMB)
+}
+
+// checkJobs 初始化完成前定时任务还没有启动，任务还没有运行过时以初始化完成的时间为准
+func (h *healthService) checkJobs() []HealthCheck {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	checks := make([]HealthCheck, 0, len(h.maxDelay))
+	for _, job := range []string{JobExecTask, JobGenerateTask} {
+		name := "job_" + job
+		if !h.initialized {
+			checks = append(checks, healthyCheck(name, "waiting for init"))
+			continue
+		}
+
+		last, ok := h.lastRun[job]
+		if !ok {
+			last = h.started
+		}
+
+		switch since := time.Since(last); {
+		case since > h.maxDelay[job]:
+			checks = append(checks, newHealthCheck(name, fmt.Errorf("not run for %s", since.Round(time.Second))))
+		case ok:
+			checks = append(checks, healthyCheck(name, "last run at %s", last.Format(time.RFC3339)))
+		default:
+			checks = append(checks, healthyCheck(name, "not run yet"))
+		}
+	}
+
+	return checks
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func findHealthCheck(t *testing.T, r HealthReport, name string) HealthCheck {
+	t.Helper()
+
+	for _, c := range r.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+
+	t.Fatalf("check %s not found in %+v", name, r.Checks)
+
+	return HealthCheck{}
+}
+
+// TestHealthServiceInit 初始化超过定时任务的最大延迟时，存活检查不能失败，完成后重新计算延迟
+func TestHealthServiceInit(t *testing.T) {
+	cfg := HealthConfig{}
+	cfg.SetDefault()
+
+	h := NewHealthService(&cfg, func(context.Context) error { return nil })
+	h.started = time.Now().Add(-3 * time.Hour)
+
+	if r := h.Liveness(context.Background()); !r.Healthy() {
+		t.Errorf("liveness should pass during init: %+v", r.Checks)
+	}
+
+	ready := h.Readiness(context.Background())
+	if ready.Healthy() || findHealthCheck(t, ready, "init").Status != HealthFail {
+		t.Errorf("readiness should fail during init: %+v", ready.Checks)
+	}
+
+	h.MarkInitialized()
+
+	if r := h.Liveness(context.Background()); !r.Healthy() {
+		t.Errorf("liveness should pass right after init: %+v", r.Checks)
+	}
+
+	ready = h.Readiness(context.Background())
+	if c := findHealthCheck(t, ready, "init"); c.Status != HealthOK {
+		t.Errorf("init check should pass after init: %+v", c)
+	}
+
+	for _, job := range []string{JobExecTask, JobGenerateTask} {
+		if c := findHealthCheck(t, ready, "job_"+job); c.Status != HealthOK {
+			t.Errorf("check of %s should pass right after init: %+v", job, c)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package app
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+// handleIssue 根据策略检查结果创建、更新或者关闭issue，扫描失败时无法判断漏洞是否修复，不做处理
+func (h *communityHandler) handleIssue(cfg *communityConfig, record *domain.ScanRecord) {
+	if !cfg.issueConfig.IsEnabled() || record.Policy == nil || record.HasError() {
+		return
+	}
+
+	if cfg.issueConfig.IsCveMode() {
+		h.handleCveIssues(cfg, record)
+	} else {
+		h.handleImageIssue(cfg, record)
+	}
+}
+
+func (h *communityHandler) handleImageIssue(cfg *communityConfig, record *domain.ScanRecord) {
+	if !record.IsPolicyFailed() {
+		h.closeImageIssue(cfg, record.Image)
+
+		return
+	}
+
+	h.modifyIssue(cfg, record.Image, func(issue *domain.Issue) bool {
+		return h.openIssue(cfg, issue, cfg.issueConfig.ImageIssueContent(record))
+	})
+}
+
+// closeImageIssue 先不加锁查询，镜像没有打开的issue时不需要插入记录
+func (h *communityHandler) closeImageIssue(cfg *communityConfig, image string) {
+	issue, err := h.issueRepo.Find(h.name, image)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logrus.Errorf("find issue of %s failed: %s", image, err.Error())
+		}
+
+		return
+	}
+
+	if !issue.IsOpen() {
+		return
+	}
+
+	h.modifyIssue(cfg, image, func(issue *domain.Issue) bool {
+		return issue.IsOpen() && h.closeIssue(cfg, issue)
+	})
+}
+
+func (h *communityHandler) handleCveIssues(cfg *communityConfig, record *domain.ScanRecord) {
+	var cves []string
+	if record.IsPolicyFailed() {
+		cves = record.TrackedCves(&cfg.issueConfig)
+	}
+
+	for _, cve := range cves {
+		h.modifyIssue(cfg, cve, func(issue *domain.Issue) bool {
+			if issue.IsOpen() && issue.HasImage(record.Image) {
+				return false
+			}
+
+			issue.AddImage(record.Image, record.Owner.Maintainers)
+
+			return h.openIssue(cfg, issue, cfg.issueConfig.CveIssueContent(issue))
+		})
+	}
+
+	// 镜像中已经修复的漏洞，从issue中移除该镜像，没有受影响的镜像时关闭issue
+	h.removeImageFromIssues(cfg, record.Image, cves)
+}
+
+// removeImageFromIssues 从除keep以外的打开的issue中移除镜像，没有受影响的镜像时关闭issue
+func (h *communityHandler) removeImageFromIssues(cfg *communityConfig, image string, keep []string) {
+	issues, err := h.issueRepo.FindOpen(h.name)
+	if err != nil {
+		logrus.Errorf("find open issues of %s failed: %s", h.name, err.Error())
+		return
+	}
+
+	for i := range issues {
+		if !issues[i].HasImage(image) || slices.Contains(keep, issues[i].Key) {
+			continue
+		}
+
+		// 加锁后重新检查，其他副本可能已经修改了该issue
+		h.modifyIssue(cfg, issues[i].Key, func(issue *domain.Issue) bool {
+			if !issue.IsOpen() || !issue.HasImage(image) {
+				return false
+			}
+
+			issue.RemoveImage(image)
+			if len(issue.Images) == 0 {
+				return h.closeIssue(cfg, issue)
+			}
+
+			return h.openIssue(cfg, issue, cfg.issueConfig.CveIssueContent(issue))
+		})
+	}
+}
+
+// closeIssuesOfImages 镜像从配置中删除后不会再被扫描，关闭或者更新其对应的issue
+func (h *communityHandler) closeIssuesOfImages(images []string) {
+	cfg := h.getConfig()
+	if cfg == nil || !cfg.issueConfig.IsEnabled() {
+		return
+	}
+
+	for _, image := range images {
+		if cfg.issueConfig.IsCveMode() {
+			h.removeImageFromIssues(cfg, image, nil)
+		} else {
+			h.closeImageIssue(cfg, image)
+		}
+	}
+}
+
+// modifyIssue 通过数据库锁定issue，多个副本不会重复创建同一个issue，issue还没有创建时使用配置中的仓库
+func (h *communityHandler) modifyIssue(cfg *communityConfig, key string, modify func(issue *domain.Issue) bool) {
+	err := h.issueRepo.Modify(h.name, key, func(issue *domain.Issue) bool {
+		if issue.Number == "" {
+			issue.Repo = cfg.issueConfig.Repo
+		}
+
+		return modify(issue)
+	})
+	if err != nil {
+		logrus.Errorf("modify issue of %s failed: %s", key, err.Error())
+	}
+}
+
+// openIssue 返回是否需要保存issue，内容没有变化时不再更新
+func (h *communityHandler) openIssue(cfg *communityConfig, issue *domain.Issue, content domain.IssueContent) bool {
+	digest := content.Digest()
+	if issue.IsOpen() && issue.ContentDigest == digest {
+		return false
+	}
+
+	var err error
+	if issue.Number == "" {
+		issue.Number, err = cfg.platform.CreateIssue(issue.Repo, content)
+	} else {
+		err = cfg.platform.UpdateIssue(issue.Repo, issue.Number, content)
+	}
+
+	if err != nil {
+		logrus.Errorf("create or update issue of %s failed: %s", issue.Key, err.Error())
+	} else {
+		issue.ContentDigest = digest
+	}
+
+	// 只要issue已经创建就需要记录，避免重复创建
+	if issue.Number == "" {
+		return false
+	}
+
+	issue.State = domain.IssueStateOpen
+
+	return true
+}
+
+func (h *communityHandler) closeIssue(cfg *communityConfig, issue *domain.Issue) bool {
+	if err := cfg.platform.CloseIssue(issue.Repo, issue.Number); err != nil {
+		logrus.Errorf("close issue %s of %s failed: %s", issue.Number, issue.Key, err.Error())
+		return false
+	}
+
+	issue.State = domain.IssueStateClosed
+
+	return true
+}
@@ -0,0 +1,19 @@
+package app
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/message"
+)
+
+// publish 发布失败不影响扫描流程，只记录日志
+func publish(p message.Publisher, e domain.LifecycleEvent) {
+	if p == nil {
+		return
+	}
+
+	if err := p.Publish(e); err != nil {
+		logrus.Errorf("publish event %s of %s failed: %s", e.Type, e.Subject, err.Error())
+	}
+}
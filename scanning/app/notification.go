@@ -0,0 +1,47 @@
+package app
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+// notifyNewFindings 任意一次扫描失败时无法准确对比，不发送通知
+func (h *communityHandler) notifyNewFindings(record, previous *domain.ScanRecord) {
+	if record.HasError() || previous.HasError() {
+		return
+	}
+
+	vulns := record.NewVulnerabilities(previous)
+	if len(vulns) == 0 {
+		return
+	}
+
+	h.notify(domain.NewFindingsEvent(record, vulns))
+}
+
+// trackFailure 更新任务的执行结果，连续失败次数达到阈值时通知一次，被隔离时再通知一次
+func (h *communityHandler) trackFailure(task *domain.Task, err error, policy *domain.RetryPolicy) {
+	if err == nil {
+		task.Succeed()
+
+		return
+	}
+
+	quarantined := task.Fail(err, policy)
+
+	if task.Failures == h.failureThreshold {
+		h.notify(domain.NewScanFailedEvent(task, task.Failures, err))
+	}
+
+	if quarantined {
+		logrus.Warnf("task %s is quarantined after %d failures", task.UniqueKey(), task.Failures)
+		h.notify(domain.NewTaskQuarantinedEvent(task))
+	}
+}
+
+func (h *communityHandler) notify(e domain.Event) {
+	if err := h.notifier.Notify(e); err != nil {
+		logrus.Errorf("notify %s of %s failed: %s", e.Type, h.name, err.Error())
+	}
+}
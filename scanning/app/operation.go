@@ -0,0 +1,125 @@
+package app
+
+import (
+	"errors"
+	"slices"
+
+	"gorm.io/gorm"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+)
+
+var (
+	ErrTaskNotFound      = errors.New("task not found")
+	ErrCommunityNotFound = errors.New("community not found")
+	ErrScanQueueFull     = errors.New("scan queue is full")
+)
+
+// CommunityStatus Running为所有副本上正在扫描的任务数
+type CommunityStatus struct {
+	Name           string
+	Paused         bool
+	Weight         int
+	MaxConcurrency int
+	Running        int
+}
+
+func (t *taskService) ListTasks(opt *repository.TaskListOption) ([]domain.Task, int64, error) {
+	return t.repos.Task.List(opt)
+}
+
+func (t *taskService) GetTask(id int64) (domain.Task, error) {
+	task, err := t.repos.Task.FindById(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return task, ErrTaskNotFound
+	}
+
+	return task, err
+}
+
+// ScanNow 标记任务需要立即扫描，不受扫描间隔和暂停状态的限制
+func (t *taskService) ScanNow(id int64) error {
+	task, err := t.GetTask(id)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := t.getHandler(task.Community); !ok {
+		return ErrCommunityNotFound
+	}
+
+	if err = t.repos.Task.RequestScan(id); err != nil {
+		return err
+	}
+
+	t.wakeWorkers()
+
+	return nil
+}
+
+func (t *taskService) PauseTask(id int64) error {
+	return t.setTaskPaused(id, true)
+}
+
+func (t *taskService) ResumeTask(id int64) error {
+	return t.setTaskPaused(id, false)
+}
+
+func (t *taskService) setTaskPaused(id int64, paused bool) error {
+	if _, err := t.GetTask(id); err != nil {
+		return err
+	}
+
+	return t.repos.Task.SetPaused([]int64{id}, paused)
+}
+
+func (t *taskService) ListCommunities() ([]CommunityStatus, error) {
+	names := make([]string, len(t.communities))
+	for i := range t.communities {
+		names[i] = t.communities[i].Name
+	}
+
+	running, err := t.repos.Task.CountRunning(names)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]CommunityStatus, 0, len(t.communities))
+	for _, c := range t.communities {
+		paused, err := t.repos.Community.IsPaused(c.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, CommunityStatus{
+			Name:           c.Name,
+			Paused:         paused,
+			Weight:         c.Scheduling.GetWeight(),
+			MaxConcurrency: c.Scheduling.MaxConcurrency,
+			Running:        running[c.Name],
+		})
+	}
+
+	return result, nil
+}
+
+// PauseCommunity 暂停社区后该社区的任务不再定时扫描，任务本身的暂停状态保持不变
+func (t *taskService) PauseCommunity(name string) error {
+	return t.setCommunityPaused(name, true)
+}
+
+func (t *taskService) ResumeCommunity(name string) error {
+	return t.setCommunityPaused(name, false)
+}
+
+func (t *taskService) setCommunityPaused(name string, paused bool) error {
+	exist := slices.ContainsFunc(t.communities, func(c domain.Community) bool {
+		return c.Name == name
+	})
+	if !exist {
+		return ErrCommunityNotFound
+	}
+
+	return t.repos.Community.SetPaused(name, paused)
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+)
+
+var ErrResultNotFound = errors.New("scan result not found")
+
+type ResultService interface {
+	LatestResult(taskId int64, arch string) (domain.ScanRecord, error)
+	ListResults(taskId int64, arch string, page, size int) ([]domain.ScanRecord, int64, error)
+}
+
+func NewResultService(taskRepo repository.Task, recordRepo repository.ScanRecord) *resultService {
+	return &resultService{
+		taskRepo:   taskRepo,
+		recordRepo: recordRepo,
+	}
+}
+
+type resultService struct {
+	taskRepo   repository.Task
+	recordRepo repository.ScanRecord
+}
+
+func (s *resultService) checkTask(taskId int64) error {
+	_, err := s.taskRepo.FindById(taskId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrTaskNotFound
+	}
+
+	return err
+}
+
+// LatestResult arch为空时返回所有架构的结果
+func (s *resultService) LatestResult(taskId int64, arch string) (domain.ScanRecord, error) {
+	if err := s.checkTask(taskId); err != nil {
+		return domain.ScanRecord{}, err
+	}
+
+	record, err := s.recordRepo.FindLatest(taskId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return record, ErrResultNotFound
+	}
+
+	return record.FilterArch(arch), err
+}
+
+func (s *resultService) ListResults(taskId int64, arch string, page, size int) ([]domain.ScanRecord, int64, error) {
+	if err := s.checkTask(taskId); err != nil {
+		return nil, 0, err
+	}
+
+	records, total, err := s.recordRepo.FindByTask(taskId, page, size)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i := range records {
+		records[i] = records[i].FilterArch(arch)
+	}
+
+	return records, total, nil
+}
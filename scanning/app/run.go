@@ -0,0 +1,42 @@
+package app
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+)
+
+type RunService interface {
+	ListRuns(opt *repository.ScanRunListOption) ([]domain.ScanRun, int64, error)
+}
+
+func NewRunService(taskRepo repository.Task, runRepo repository.ScanRun) *runService {
+	return &runService{
+		taskRepo: taskRepo,
+		runRepo:  runRepo,
+	}
+}
+
+type runService struct {
+	taskRepo repository.Task
+	runRepo  repository.ScanRun
+}
+
+// ListRuns 指定任务时任务需要存在，已删除任务的历史仍然可以按社区查询
+func (s *runService) ListRuns(opt *repository.ScanRunListOption) ([]domain.ScanRun, int64, error) {
+	if opt.TaskId != 0 {
+		_, err := s.taskRepo.FindById(opt.TaskId)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, ErrTaskNotFound
+		}
+
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return s.runRepo.List(opt)
+}
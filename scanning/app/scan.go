@@ -0,0 +1,218 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/opensourceways/image-scanning/common/infrastructure/command"
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/ratelimiter"
+	"github.com/opensourceways/image-scanning/scanning/infrastructure/metrics"
+	"github.com/opensourceways/image-scanning/utils"
+)
+
+const (
+	trivyCmd = trivyResourceDir + "trivy/trivy"
+	skopeo   = "skopeo"
+
+	maxPullAttempts = 3
+)
+
+var tracer = otel.Tracer("github.com/opensourceways/image-scanning/scanning/app")
+
+// scanTarget 定时任务和临时扫描共用下载镜像和trivy扫描的流程
+type scanTarget interface {
+	ImagePath() string
+	FormatArch() []string
+	LocalImagePath(arch string) string
+}
+
+// imageScanner 下载镜像和trivy扫描，外部命令按阶段设置超时；
+// limiter由镜像拉取和标签查询共用，限制对同一个镜像站的访问频率和并发数
+type imageScanner struct {
+	runner  command.Runner
+	timeout *Timeout
+	limiter ratelimiter.RateLimiter
+}
+
+func NewImageScanner(runner command.Runner, timeout *Timeout, limiter ratelimiter.RateLimiter) *imageScanner {
+	return &imageScanner{
+		runner:  runner,
+		timeout: timeout,
+		limiter: limiter,
+	}
+}
+
+// downloadImage 返回本次拉取的镜像大小，已经在本地的镜像不重复拉取
+func (s *imageScanner) downloadImage(ctx context.Context, target scanTarget) (int64, error) {
+	var pulled int64
+	for _, arch := range target.FormatArch() {
+		exist, err := utils.PathExists(target.LocalImagePath(arch))
+		if err != nil {
+			return pulled, err
+		}
+
+		if exist {
+			continue
+		}
+
+		if err = s.pullImage(ctx, target, arch); err != nil {
+			return pulled, err
+		}
+
+		size, err := utils.DirSize(target.LocalImagePath(arch))
+		if err != nil {
+			logrus.Errorf("get size of %s failed: %s", target.LocalImagePath(arch), err.Error())
+		}
+
+		pulled += size
+	}
+
+	return pulled, nil
+}
+
+// pullImage 被镜像站限速时按限速器的退避时间重试
+func (s *imageScanner) pullImage(ctx context.Context, target scanTarget, arch string) (err error) {
+	ctx, span := tracer.Start(ctx, "downloadImage", trace.WithAttributes(
+		attribute.String("image", target.ImagePath()),
+		attribute.String("arch", arch),
+	))
+	defer func() { utils.EndSpan(span, err) }()
+
+	registry := registryOf(target)
+	for attempt := 1; ; attempt++ {
+		span.SetAttributes(attribute.Int("attempts", attempt))
+
+		err = s.copyImage(ctx, target, arch, registry)
+		if err == nil {
+			s.limiter.Observe(registry, http.StatusOK, nil)
+
+			return nil
+		}
+
+		if pullStatus(err) != http.StatusTooManyRequests {
+			return err
+		}
+
+		s.limiter.Observe(registry, http.StatusTooManyRequests, nil)
+
+		if attempt >= maxPullAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		logrus.Warnf("pull %s is rate limited by %s, retry", target.ImagePath(), registry)
+	}
+}
+
+// copyImage 失败时删除写了一半的镜像目录，否则下次会被当作已下载
+func (s *imageScanner) copyImage(ctx context.Context, target scanTarget, arch, registry string) error {
+	release, err := s.limiter.Acquire(ctx, registry)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	start := time.Now()
+	out, err := s.runner.Run(ctx, command.Cmd{
+		Name: skopeo,
+		Args: []string{
+			"copy",
+			"--override-arch", arch,
+			fmt.Sprintf("docker://%s", target.ImagePath()),
+			fmt.Sprintf("oci:./%s", target.LocalImagePath(arch)),
+		},
+		Timeout: utils.StringToDuration(s.timeout.Pull),
+	})
+	metrics.ObservePull(registry, start, err == nil)
+	if err != nil {
+		logrus.Errorf("download image %s failed: out: %s, err:%s", target.ImagePath(), out, err.Error())
+
+		if rerr := os.RemoveAll(target.LocalImagePath(arch)); rerr != nil {
+			logrus.Errorf("remove local image %s failed: %s", target.LocalImagePath(arch), rerr.Error())
+		}
+	}
+
+	return err
+}
+
+// pullStatus 从skopeo的stderr中识别镜像站返回的限速错误，例如Docker Hub的toomanyrequests，
+// 命令行中包含镜像名，不参与匹配；没有执行完的命令（超时、取消）和其他错误返回0
+func pullStatus(err error) int {
+	var cmdErr *command.Error
+	if !errors.As(err, &cmdErr) || cmdErr.ExitCode <= 0 {
+		return 0
+	}
+
+	stderr := strings.ToLower(cmdErr.Stderr)
+	if strings.Contains(stderr, "toomanyrequests") || strings.Contains(stderr, "429 too many requests") {
+		return http.StatusTooManyRequests
+	}
+
+	return 0
+}
+
+func registryOf(target scanTarget) string {
+	registry, _, _ := strings.Cut(target.ImagePath(), "/")
+
+	return registry
+}
+
+func (s *imageScanner) scanImage(
+	ctx context.Context, target scanTarget, vex domain.VexStatements,
+) map[string]domain.ArchResult {
+	ars := make(map[string]domain.ArchResult, len(target.FormatArch()))
+	for _, arch := range target.FormatArch() {
+		param := []string{
+			"image",
+			"--quiet",
+			"--skip-db-update",
+			"-f", "json",
+			"--scanners", "vuln",
+			"--cache-dir", trivyResourceDir,
+			"--input",
+			target.LocalImagePath(arch),
+		}
+
+		archCtx, span := tracer.Start(ctx, "handleArch", trace.WithAttributes(
+			attribute.String("image", target.ImagePath()),
+			attribute.String("arch", arch),
+		))
+
+		ar := s.handleArch(archCtx, param)
+		vex.Apply(target.ImagePath(), &ar)
+		ars[arch] = ar
+
+		span.SetAttributes(attribute.Int("suppressed", len(ar.Suppressed)))
+		utils.EndSpan(span, ar.Err)
+	}
+
+	return ars
+}
+
+func (s *imageScanner) handleArch(ctx context.Context, param []string) domain.ArchResult {
+	var ar domain.ArchResult
+	out, err := s.runner.Run(ctx, command.Cmd{
+		Name:    trivyCmd,
+		Args:    param,
+		Timeout: utils.StringToDuration(s.timeout.Scan),
+	})
+	if err != nil {
+		ar.Err = err
+	} else {
+		ar.Err = json.Unmarshal([]byte(out), &ar.ScanResult)
+	}
+
+	return ar
+}
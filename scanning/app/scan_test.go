@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+// recordLimiter 不限速，只记录镜像站返回的状态
+type recordLimiter struct {
+	mu    sync.Mutex
+	codes []int
+}
+
+func (l *recordLimiter) Acquire(ctx context.Context, registry string) (func(), error) {
+	return func() {}, nil
+}
+
+func (l *recordLimiter) Observe(registry string, code int, header http.Header) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.codes = append(l.codes, code)
+}
+
+func (l *recordLimiter) Codes() []int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]int(nil), l.codes...)
+}
+
+func TestPullImageRetriesOnlyWhenThrottled(t *testing.T) {
+	cases := []struct {
+		name      string
+		image     string
+		failure   error
+		wantCalls int
+		wantCodes []int
+	}{
+		{
+			name:      "succeeded",
+			image:     testImage,
+			wantCalls: 1,
+			wantCodes: []int{http.StatusOK},
+		},
+		{
+			name:      "throttled",
+			image:     testImage,
+			failure:   errors.New("reading manifest 24.03: toomanyrequests: You have reached your pull rate limit"),
+			wantCalls: maxPullAttempts,
+			wantCodes: []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests},
+		},
+		{
+			// 镜像名中包含toomanyrequests不能被当作限速
+			name:      "image name looks like throttling",
+			image:     "quay.io/toomanyrequests/app:1.0",
+			failure:   errors.New("reading manifest 1.0: manifest unknown"),
+			wantCalls: 1,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			runner := newTestRunner(t)
+			if c.failure != nil {
+				runner.Fail("docker://", c.failure)
+			}
+
+			limiter := &recordLimiter{}
+			scanner := newTestScannerWith(t, runner)
+			scanner.limiter = limiter
+
+			target, err := domain.NewAdhocScan("pull", c.image, []string{"amd64"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = scanner.pullImage(context.Background(), &target, "amd64")
+			if (err != nil) != (c.failure != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if n := len(runner.Calls()); n != c.wantCalls {
+				t.Errorf("want %d pulls, got %d", c.wantCalls, n)
+			}
+
+			if codes := limiter.Codes(); len(codes) != len(c.wantCodes) || (len(codes) > 0 && codes[0] != c.wantCodes[0]) {
+				t.Errorf("want observed %v, got %v", c.wantCodes, codes)
+			}
+		})
+	}
+}
@@ -1,48 +1,139 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path"
 	"sync"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/message"
 	"github.com/opensourceways/image-scanning/scanning/domain/platform"
 	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+	"github.com/opensourceways/image-scanning/scanning/infrastructure/metrics"
+	"github.com/opensourceways/image-scanning/scanning/infrastructure/notifierimpl"
 	"github.com/opensourceways/image-scanning/scanning/infrastructure/platformimpl"
+	"github.com/opensourceways/image-scanning/utils"
 )
 
-var (
-	handlers      map[string]*communityHandler
-	scanConfigSha map[string]string
-)
+// cancelTimeout 取消扫描后等待子进程退出、中断状态写回的时间
+const cancelTimeout = 10 * time.Second
 
 type TaskService interface {
 	GenerateTask()
 	ExecTask()
 	ClearImages()
+
+	ListTasks(opt *repository.TaskListOption) ([]domain.Task, int64, error)
+	GetTask(id int64) (domain.Task, error)
+	ScanNow(id int64) error
+	PauseTask(id int64) error
+	ResumeTask(id int64) error
+	ListCommunities() ([]CommunityStatus, error)
+	PauseCommunity(name string) error
+	ResumeCommunity(name string) error
+
+	Shutdown(ctx context.Context)
 }
 
-func NewTaskService(cs []domain.Community, con Concurrency, repo repository.Task) *taskService {
-	return &taskService{
+// Repositories 任务服务使用的仓库，按名称赋值，避免同类型的参数传错位置
+type Repositories struct {
+	Task      repository.Task
+	Record    repository.ScanRecord
+	Issue     repository.Issue
+	Community repository.Community
+	Finding   repository.Finding
+	Run       repository.ScanRun
+}
+
+func NewTaskService(
+	cs []domain.Community, con Concurrency, retry *Retry, repos Repositories,
+	publisher message.Publisher, scanner *imageScanner, isLeader func() bool,
+) *taskService {
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &taskService{
+		ctx:         ctx,
+		cancel:      cancel,
+		stop:        make(chan struct{}),
 		communities: cs,
-		repo:        repo,
-		taskChan:    make(chan domain.Task, 1000),
+		repos:       repos,
+		publisher:   publisher,
+		scanner:     scanner,
+		newPlatform: newPlatform,
+		isLeader:    isLeader,
+		handlers:    make(map[string]*communityHandler),
+		owner:       workerOwner(),
+		wake:        make(chan struct{}, con.Num),
+		fairQueue:   newFairQueue(),
 		concurrency: con,
+		retryPolicy: retry.policy(),
 	}
+
+	metrics.RegisterGaugeFunc("queue_depth", "Number of tasks waiting in the queue.",
+		prometheus.Labels{"queue": "scheduled"}, func() float64 {
+			communities, scheduled := t.claimableCommunities()
+			n, err := repos.Task.CountDue(communities, scheduled)
+			if err != nil {
+				logrus.Errorf("count due tasks failed: %s", err.Error())
+			}
+
+			return float64(n)
+		},
+	)
+
+	return t
 }
 
 type taskService struct {
-	repo        repository.Task
+	repos       Repositories
 	communities []domain.Community
-
-	mu          sync.Mutex
-	taskChan    chan domain.Task
+	publisher   message.Publisher
+	scanner     *imageScanner
+	newPlatform func(c *domain.Community) platform.Platform
+	isLeader    func() bool
+
+	// generateLock 避免启动时和定时任务同时生成任务；handlers由GenerateTask写入，worker和接口读取
+	generateLock sync.Mutex
+	mu           sync.RWMutex
+	handlers     map[string]*communityHandler
+
+	// owner 标识本实例持有的租约，wake用于唤醒等待任务的worker
+	owner       string
+	wake        chan struct{}
+	claimLock   sync.Mutex
+	fairQueue   *fairQueue
 	concurrency Concurrency
+	retryPolicy domain.RetryPolicy
+
+	// ctx在退出等待超时后取消，结束正在运行的扫描；stop关闭后不再派发新的任务
+	ctx         context.Context
+	cancel      context.CancelFunc
+	stop        chan struct{}
+	stopOnce    sync.Once
+	workersOnce sync.Once
+	workers     sync.WaitGroup
 }
 
-func (t *taskService) getPlatform(c *domain.Community) platform.Platform {
+// workerOwner 同一主机上可能运行多个实例，加上随机后缀区分
+func workerOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return hostname + "-" + uuid.NewString()[:8]
+}
+
+// newPlatform 测试时替换为内存中的实现
+func newPlatform(c *domain.Community) platform.Platform {
 	switch c.Platform {
 	case domain.PlatformGitee:
 		return platformimpl.NewGiteeImpl(c)
@@ -54,109 +145,434 @@ func (t *taskService) getPlatform(c *domain.Community) platform.Platform {
 }
 
 func (t *taskService) GenerateTask() {
+	t.generateLock.Lock()
+	defer t.generateLock.Unlock()
+
+	ctx, span := tracer.Start(context.Background(), "GenerateTask")
+	defer span.End()
+
 	for _, c := range t.communities {
-		plat := t.getPlatform(&c)
-		if plat == nil {
-			logrus.Errorf("unsupported platform %v", c)
-			continue
-		}
+		t.generateCommunityTask(ctx, c)
+	}
+}
 
-		scanConfig, sha, err := plat.DownloadScanConfig()
-		if err != nil {
-			logrus.Errorf("get scan config of %s failed: %s", c.Name, err.Error())
-			continue
-		}
+func (t *taskService) generateCommunityTask(ctx context.Context, c domain.Community) {
+	ctx, span := tracer.Start(ctx, "generateCommunityTask", trace.WithAttributes(
+		attribute.String("community", c.Name),
+	))
+	defer span.End()
 
-		if t.shaCheckNotChange(c.Name, sha) {
-			logrus.Infof("sha of %s not change", c.Name)
-			continue
-		}
+	plat := t.newPlatform(&c)
+	if plat == nil {
+		logrus.Errorf("unsupported platform %v", c)
+		return
+	}
+
+	scanConfig, sha, err := downloadScanConfig(ctx, plat)
+	if err != nil {
+		logrus.Errorf("get scan config of %s failed: %s", c.Name, err.Error())
+		return
+	}
+
+	if err = scanConfig.Validate(); err != nil {
+		logrus.Errorf("invalid scan config of %s: %s", c.Name, err.Error())
+		return
+	}
+
+	scanTimes, err := scanConfig.Scanner.Global.ScanTimes()
+	if err != nil {
+		logrus.Errorf("invalid scan windows of %s: %s", c.Name, err.Error())
+		return
+	}
+
+	vex := loadVex(plat, scanConfig.Vex)
 
+	handler, ok := t.getHandler(c.Name)
+	if !ok {
+		handler = newCommunityHandler(
+			c, t.repos, notifierimpl.NewNotifier(c.Notification.Webhooks), t.publisher, t.scanner,
+		)
+	}
+
+	if ok && handler.getConfig().sha == sha {
+		// VEX文档独立于扫描配置更新，配置没有变化时也要刷新
+		handler.setVex(vex)
+	} else {
 		plat.SetOutput(scanConfig.Scanner.Global.Output)
-		handler := newCommunityHandler(c, t.repo, plat)
-		handler.generateTask(scanConfig)
+		handler.setConfig(newCommunityConfig(plat, sha, &scanConfig, vex, scanTimes))
+	}
 
-		if len(handlers) == 0 {
-			handlers = make(map[string]*communityHandler)
-		}
+	// 每个副本都需要加载配置才能执行任务，任务只由leader同步到数据库
+	if !t.isLeader() {
+		logrus.Infof("not the leader, skip syncing tasks of %s", c.Name)
+	} else if handler.syncedSha == sha {
+		logrus.Infof("sha of %s not change", c.Name)
+	} else {
+		handler.syncTasks(ctx, &scanConfig)
+		handler.syncedSha = sha
+	}
 
+	// 首次加载配置后才加入，避免worker使用还没有配置的handler
+	if !ok {
 		t.mu.Lock()
-		handlers[c.Name] = handler
+		t.handlers[c.Name] = handler
 		t.mu.Unlock()
 	}
 }
 
-func (t *taskService) shaCheckNotChange(communityName, newSha string) bool {
-	if len(scanConfigSha) == 0 {
-		scanConfigSha = make(map[string]string)
-	}
+func (t *taskService) getHandler(community string) (*communityHandler, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 
-	oldSha, ok := scanConfigSha[communityName]
-	scanConfigSha[communityName] = newSha
-	if !ok {
-		return false
+	handler, ok := t.handlers[community]
+
+	return handler, ok
+}
+
+func (t *taskService) allHandlers() []*communityHandler {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	handlers := make([]*communityHandler, 0, len(t.handlers))
+	for _, handler := range t.handlers {
+		handlers = append(handlers, handler)
 	}
 
-	return oldSha == newSha
+	return handlers
+}
+
+func downloadScanConfig(ctx context.Context, plat platform.Platform) (cfg domain.ScanConfig, sha string, err error) {
+	_, span := tracer.Start(ctx, "DownloadScanConfig")
+	defer func() { utils.EndSpan(span, err) }()
+
+	return plat.DownloadScanConfig()
 }
 
+// ExecTask 唤醒空闲的worker到数据库领取到期的任务，正在扫描的任务持有租约，不会被重复领取
 func (t *taskService) ExecTask() {
-	// 首次执行任务或者配置文件新增任务时，拉取镜像十分费时，可能会跨越到下次定时任务执行的时间，
-	// 如果channel还有未执行完的任务，则跳过，避免重复执行
-	if len(t.taskChan) > 0 {
+	if t.stopped() {
 		return
 	}
 
-	go t.loadTask()
+	t.refreshTaskMetrics()
+
+	t.workersOnce.Do(t.handleTaskConcurrently)
 
-	t.handleTaskConcurrently()
+	t.wakeWorkers()
 }
 
-func (t *taskService) loadTask() {
-	defer t.recovery()
+func (t *taskService) stopped() bool {
+	select {
+	case <-t.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t *taskService) wakeWorkers() {
+	for i := 0; i < t.concurrency.Num; i++ {
+		select {
+		case t.wake <- struct{}{}:
+		default:
+			return
+		}
+	}
+}
 
-	for _, handler := range handlers {
+func (t *taskService) refreshTaskMetrics() {
+	for _, handler := range t.allHandlers() {
 		tasks, err := handler.repo.FindAll(handler.name)
 		if err != nil {
-			logrus.Errorf("find all tasks of %s failed when exec task: %s", handler.name, err.Error())
+			logrus.Errorf("find all tasks of %s failed: %s", handler.name, err.Error())
 			continue
 		}
 
-		for _, task := range tasks {
-			if !task.IsNeedToScan() {
-				continue
-			}
+		setTaskMetrics(handler.name, tasks)
+	}
+}
+
+func setTaskMetrics(community string, tasks []domain.Task) {
+	statuses := make(map[string]int, len(domain.TaskStatuses))
+	for _, status := range domain.TaskStatuses {
+		statuses[status] = 0
+	}
+
+	paused := 0
+	for i := range tasks {
+		if tasks[i].Paused {
+			paused++
+		}
+
+		statuses[tasks[i].Status]++
+	}
+
+	metrics.SetTasks(community, len(tasks), paused)
+	metrics.SetTaskStatuses(community, statuses)
+}
 
-			t.taskChan <- task
+// claimableCommunities 返回可以领取任务的社区，以及其中处于扫描窗口、可以开始定时扫描的社区
+func (t *taskService) claimableCommunities() (communities, scheduled []string) {
+	now := time.Now()
+
+	for _, handler := range t.allHandlers() {
+		communities = append(communities, handler.name)
+
+		if handler.getConfig().scanTimes.Allow(now) {
+			scheduled = append(scheduled, handler.name)
 		}
 	}
+
+	return
 }
 
+// handleTaskConcurrently 常驻的worker，只启动一次，没有到期的任务时等待唤醒，退出时等待worker处理完当前任务
 func (t *taskService) handleTaskConcurrently() {
 	for i := 1; i <= t.concurrency.Num; i++ {
+		t.workers.Add(1)
+
 		go func() {
-			defer t.recovery()
+			defer t.workers.Done()
 
-			for task := range t.taskChan {
-				handler, ok := handlers[task.Community]
-				if !ok {
+			for !t.stopped() {
+				if task, ok := t.claimTask(); ok {
+					t.execTask(task)
 					continue
 				}
 
-				// 提前写入时间，防止扫描时间不停的向后偏移
-				task.UpdateLastScanTime()
-				if err := handler.repo.Save(task); err != nil {
-					logrus.Errorf("save task %s when exec failed: %s", task.UniqueKey(), err.Error())
-				}
-
-				if err := handler.handleTask(&task); err != nil {
-					logrus.Errorf("handle task %s failed: %s", task.UniqueKey(), err.Error())
+				select {
+				case <-t.wake:
+				case <-t.stop:
+					return
 				}
 			}
 		}()
 	}
 }
 
+// claimTask 按加权公平队列的顺序依次从各社区领取，跳过达到并发上限的社区；
+// 并发数来自数据库，多个副本同时领取时可能短暂超出上限
+func (t *taskService) claimTask() (domain.Task, bool) {
+	t.claimLock.Lock()
+	defer t.claimLock.Unlock()
+
+	communities, scheduled := t.claimableCommunities()
+
+	running, err := t.repos.Task.CountRunning(communities)
+	if err != nil {
+		logrus.Errorf("count running tasks failed: %s", err.Error())
+		return domain.Task{}, false
+	}
+
+	var idle []string
+	for _, name := range t.fairQueue.order(communities) {
+		handler, ok := t.getHandler(name)
+		if !ok {
+			continue
+		}
+
+		if limit := handler.scheduling.MaxConcurrency; limit > 0 && running[name] >= limit {
+			continue
+		}
+
+		tasks, err := t.repos.Task.Claim(&repository.ClaimOption{
+			Owner:       t.owner,
+			Communities: []string{name},
+			Scheduled:   scheduled,
+			Limit:       1,
+			Lease:       t.concurrency.lease(),
+		})
+		if err != nil {
+			logrus.Errorf("claim task of %s failed: %s", name, err.Error())
+			continue
+		}
+
+		if len(tasks) == 0 {
+			idle = append(idle, name)
+			continue
+		}
+
+		t.fairQueue.charge(name, handler.scheduling.GetWeight(), idle)
+
+		return tasks[0], true
+	}
+
+	return domain.Task{}, false
+}
+
+func (t *taskService) execTask(task domain.Task) {
+	defer t.recovery()
+
+	// 没有正常结束的任务恢复状态并释放租约，其他worker或者新实例启动后会重新扫描
+	claimed := task
+	completed := false
+	defer func() {
+		if !completed {
+			t.releaseTask(&claimed)
+		}
+	}()
+
+	handler, ok := t.getHandler(task.Community)
+	if !ok {
+		return
+	}
+
+	// 记录开始的时间，防止扫描时间不停的向后偏移
+	task.UpdateLastScanTime()
+
+	publish(t.publisher, domain.NewScanStartedEvent(&task))
+
+	ctx, cancel := context.WithCancel(t.ctx)
+	defer cancel()
+
+	stopHeartbeat := t.heartbeat(&task, cancel)
+	defer stopHeartbeat()
+
+	ctx, span := tracer.Start(ctx, "ScanTask", trace.WithAttributes(
+		attribute.Int64("task.id", task.Id),
+		attribute.String("image", task.ImagePath()),
+		attribute.String("community", task.Community),
+	))
+
+	progress := t.newTaskProgress(&task)
+
+	record, err := handler.handleTask(ctx, &task, &t.retryPolicy, progress)
+	if err != nil {
+		logrus.Errorf("handle task %s failed: %s", task.UniqueKey(), err.Error())
+	}
+
+	utils.EndSpan(span, err)
+
+	publish(t.publisher, domain.NewScanFinishedEvent(&task, record, err))
+
+	progress.run.Finish(record, err, ctx.Err() != nil)
+	if err := t.repos.Run.Add(&progress.run); err != nil {
+		logrus.Errorf("add scan run of %s failed: %s", task.UniqueKey(), err.Error())
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	stopHeartbeat()
+
+	if err = t.repos.Task.Complete(task, t.owner); err != nil {
+		logrus.Errorf("complete task %s failed: %s", task.UniqueKey(), err.Error())
+	}
+
+	completed = true
+}
+
+// heartbeat 定期续约，租约丢失时任务可能已经被其他worker领取，取消本次扫描
+func (t *taskService) heartbeat(task *domain.Task, cancel context.CancelFunc) func() {
+	lease := t.concurrency.lease()
+	done := make(chan struct{})
+	exited := make(chan struct{})
+
+	go func() {
+		defer close(exited)
+
+		ticker := time.NewTicker(lease / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			err := t.repos.Task.RenewLease(task.Id, t.owner, lease)
+			if errors.Is(err, repository.ErrLeaseLost) {
+				logrus.Errorf("lease of task %s lost, cancel the scan", task.UniqueKey())
+				cancel()
+
+				return
+			}
+
+			if err != nil {
+				logrus.Errorf("renew lease of task %s failed: %s", task.UniqueKey(), err.Error())
+			}
+		}
+	}()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-exited
+		})
+	}
+}
+
+// taskProgress 同步任务所处的阶段，并记录到本次的执行历史
+type taskProgress struct {
+	t    *taskService
+	task *domain.Task
+	run  domain.ScanRun
+}
+
+func (t *taskService) newTaskProgress(task *domain.Task) *taskProgress {
+	p := &taskProgress{
+		t:    t,
+		task: task,
+		run:  domain.NewScanRun(task, t.owner),
+	}
+
+	if updatedAt, err := dbUpdatedAt(); err == nil {
+		p.run.TrivyDBUpdatedAt = updatedAt
+	}
+
+	return p
+}
+
+func (p *taskProgress) enterStage(status string) {
+	p.run.EnterStage(status)
+	p.task.SetStatus(status)
+
+	if err := p.t.repos.Task.SetStatus(p.task.Id, p.t.owner, status); err != nil {
+		logrus.Errorf("set status of task %s to %s failed: %s", p.task.UniqueKey(), status, err.Error())
+	}
+}
+
+func (p *taskProgress) addBytesPulled(n int64) {
+	p.run.AddBytesPulled(n)
+}
+
+func (t *taskService) releaseTask(task *domain.Task) {
+	err := t.repos.Task.Release(*task, t.owner)
+	if err != nil && !errors.Is(err, repository.ErrLeaseLost) {
+		logrus.Errorf("release task %s failed: %s", task.UniqueKey(), err.Error())
+	}
+}
+
+// Shutdown 停止派发任务并等待正在运行的扫描结束，ctx超时后取消剩余的扫描
+func (t *taskService) Shutdown(ctx context.Context) {
+	t.stopOnce.Do(func() { close(t.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		t.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-ctx.Done():
+	}
+
+	logrus.Warn("grace period exceeded, cancel the running scans")
+	t.cancel()
+
+	select {
+	case <-done:
+	case <-time.After(cancelTimeout):
+		logrus.Error("running scans are not finished after being canceled")
+	}
+}
+
 func (t *taskService) recovery() {
 	if r := recover(); r != nil {
 		logrus.Errorf("exec task panic %v", r)
@@ -0,0 +1,346 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/platform"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+	"github.com/opensourceways/image-scanning/scanning/infrastructure/fakeimpl"
+)
+
+const (
+	testCommunity = "openeuler"
+	testIssueRepo = "openeuler/image-issues"
+	failedImage   = "quay.io/openeuler/openeuler:22.03"
+	extraImage    = "quay.io/openeuler/openeuler:20.03"
+)
+
+// fakePlatform fakeimpl的平台记录上传的报告和创建的issue
+type fakePlatform interface {
+	platform.Platform
+	SetScanConfig(cfg domain.ScanConfig, sha string)
+	Uploaded(path string) (string, bool)
+	Issues() map[string]domain.IssueContent
+}
+
+type testTaskService struct {
+	*taskService
+
+	runner   fakeRunner
+	platform fakePlatform
+	tasks    repository.Task
+	records  repository.ScanRecord
+	runs     repository.ScanRun
+}
+
+func testScanConfig(tags ...string) domain.ScanConfig {
+	maxHigh := 0
+
+	image := domain.Image{Image: "openeuler"}
+	for _, tag := range tags {
+		image.Tags = append(image.Tags, domain.Tag{Tag: tag})
+	}
+
+	return domain.ScanConfig{
+		Scanner: domain.Scanner{Global: domain.Global{
+			DefaultArches:   []string{"amd64"},
+			DefaultInterval: "1d",
+			Output:          domain.Output{Repo: "openeuler/image-reports", Path: "reports"},
+		}},
+		Images: []domain.Image{image},
+		Policy: domain.Policy{MaxHigh: &maxHigh},
+		Issue:  domain.IssueConfig{Repo: testIssueRepo},
+	}
+}
+
+// newTestTaskService 仓库、平台和命令都使用fakeimpl，通过newPlatform替换代码平台
+func newTestTaskService(t *testing.T, cfg domain.ScanConfig) *testTaskService {
+	runner := newTestRunner(t)
+	plat := fakeimpl.NewPlatformImpl(cfg, "sha-1")
+
+	retry := Retry{}
+	retry.SetDefault()
+
+	s := &testTaskService{
+		runner:   runner,
+		platform: plat,
+		tasks:    fakeimpl.NewTaskImpl(),
+		records:  fakeimpl.NewScanRecordImpl(),
+		runs:     fakeimpl.NewScanRunImpl(),
+	}
+
+	s.taskService = NewTaskService(
+		[]domain.Community{{Name: testCommunity, Platform: domain.PlatformGitee}},
+		Concurrency{Num: 2, Lease: "1m"}, &retry, Repositories{
+			Task:      s.tasks,
+			Record:    s.records,
+			Issue:     fakeimpl.NewIssueImpl(),
+			Community: fakeimpl.NewCommunityImpl(),
+			Finding:   fakeimpl.NewFindingImpl(),
+			Run:       s.runs,
+		}, nil, newTestScannerWith(t, runner), func() bool { return true },
+	)
+	s.newPlatform = func(*domain.Community) platform.Platform { return plat }
+
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+
+	return s
+}
+
+func (s *testTaskService) findTask(t *testing.T, image string) domain.Task {
+	t.Helper()
+
+	tasks, err := s.tasks.FindAll(testCommunity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range tasks {
+		if tasks[i].ImagePath() == image {
+			return tasks[i]
+		}
+	}
+
+	t.Fatalf("task of %s not found", image)
+
+	return domain.Task{}
+}
+
+// waitRuns 等待worker执行完n次扫描，再停止worker保证任务已经完成
+func (s *testTaskService) waitRuns(t *testing.T, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		_, total, err := s.runs.List(&repository.ScanRunListOption{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if total >= int64(n) {
+			s.Shutdown(context.Background())
+
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("%d scan runs are not finished", n)
+}
+
+// waitSucceeded 配置变化时任务会被删除后重建，按任务id等待扫描成功
+func (s *testTaskService) waitSucceeded(t *testing.T, task *domain.Task) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		current, err := s.tasks.FindById(task.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, uploaded := s.platform.Uploaded(task.MarkdownPath())
+		if current.Status == domain.TaskStatusSucceeded && uploaded {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("task of %s is not succeeded", task.ImagePath())
+}
+
+func TestTaskServiceScan(t *testing.T) {
+	s := newTestTaskService(t, testScanConfig(testImage, failedImage))
+	s.runner.Fail("openeuler:22.03", errors.New("manifest unknown"))
+
+	s.GenerateTask()
+
+	tasks, err := s.tasks.FindAll(testCommunity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tasks) != 2 {
+		t.Fatalf("got %d tasks, want 2", len(tasks))
+	}
+
+	s.ExecTask()
+	s.waitRuns(t, 2)
+
+	task := s.findTask(t, testImage)
+	if task.Status != domain.TaskStatusSucceeded || task.Failures != 0 {
+		t.Errorf("task of %s: status %s, failures %d", testImage, task.Status, task.Failures)
+	}
+
+	if task.LastScanTime.IsZero() || !task.NextScanTime.After(task.LastScanTime.Add(23*time.Hour)) {
+		t.Errorf("task of %s: last scan %s, next scan %s", testImage, task.LastScanTime, task.NextScanTime)
+	}
+
+	report, ok := s.platform.Uploaded(task.MarkdownPath())
+	if !ok {
+		t.Fatalf("report of %s is not uploaded to %s", testImage, task.MarkdownPath())
+	}
+
+	if !strings.Contains(report, "openEuler-SA-2024-1001") {
+		t.Errorf("report of %s does not contain the vulnerability:\n%s", testImage, report)
+	}
+
+	record, err := s.records.FindLatest(task.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(record.Arches) != 1 || len(record.Arches[0].Vulnerabilities) != 2 {
+		t.Errorf("unexpected scan record of %s: %+v", testImage, record.Arches)
+	}
+
+	if !record.IsPolicyFailed() {
+		t.Errorf("policy of %s should fail with a high vulnerability", testImage)
+	}
+
+	failed := s.findTask(t, failedImage)
+	if failed.Status != domain.TaskStatusFailed || failed.Failures != 1 ||
+		!strings.Contains(failed.LastError, "manifest unknown") {
+		t.Errorf("task of %s: status %s, failures %d, error %q",
+			failedImage, failed.Status, failed.Failures, failed.LastError)
+	}
+
+	if !failed.RetryAt.After(time.Now()) {
+		t.Errorf("task of %s should be retried later, retry at %s", failedImage, failed.RetryAt)
+	}
+
+	if _, ok := s.platform.Uploaded(failed.MarkdownPath()); ok {
+		t.Errorf("report of %s should not be uploaded", failedImage)
+	}
+
+	if _, err := s.records.FindLatest(failed.Id); err == nil {
+		t.Errorf("scan record of %s should not be saved", failedImage)
+	}
+
+	// 只有扫描成功且策略不通过的镜像创建issue
+	issues := s.platform.Issues()
+	if len(issues) != 1 {
+		t.Fatalf("got %d issues, want 1", len(issues))
+	}
+
+	for key, issue := range issues {
+		if !strings.HasPrefix(key, testIssueRepo+"/") || !strings.Contains(issue.Title, testImage) {
+			t.Errorf("unexpected issue %s: %s", key, issue.Title)
+		}
+	}
+}
+
+func TestTaskServiceRemoveImage(t *testing.T) {
+	s := newTestTaskService(t, testScanConfig(testImage))
+
+	s.GenerateTask()
+	s.ExecTask()
+	s.waitRuns(t, 1)
+
+	if len(s.platform.Issues()) != 1 {
+		t.Fatalf("got %d issues, want 1", len(s.platform.Issues()))
+	}
+
+	// 配置中删除镜像后，任务被清理，打开的issue被关闭
+	s.platform.SetScanConfig(testScanConfig(failedImage), "sha-2")
+	s.GenerateTask()
+
+	tasks, err := s.tasks.FindAll(testCommunity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tasks) != 1 || tasks[0].ImagePath() != failedImage {
+		t.Fatalf("unexpected tasks after removing %s: %+v", testImage, tasks)
+	}
+
+	if issues := s.platform.Issues(); len(issues) != 0 {
+		t.Errorf("issue of %s is not closed: %+v", testImage, issues)
+	}
+}
+
+// TestTaskServiceConcurrent 配置变化时定时生成任务、派发任务和手动触发扫描同时进行，需要使用-race运行
+func TestTaskServiceConcurrent(t *testing.T) {
+	s := newTestTaskService(t, testScanConfig(testImage))
+
+	configs := []domain.ScanConfig{testScanConfig(testImage), testScanConfig(testImage, extraImage)}
+
+	var wg sync.WaitGroup
+	run := func(n int, f func(i int)) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < n; i++ {
+				f(i)
+			}
+		}()
+	}
+
+	// 启动时和定时任务可能同时生成任务
+	for g := 0; g < 2; g++ {
+		run(20, func(i int) {
+			s.platform.SetScanConfig(configs[i%2], fmt.Sprintf("sha-%d-%d", g, i))
+			s.GenerateTask()
+		})
+	}
+
+	run(20, func(int) {
+		s.ExecTask()
+		time.Sleep(time.Millisecond)
+	})
+
+	run(20, func(int) {
+		tasks, _, err := s.ListTasks(&repository.TaskListOption{})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		// 任务可能已经被删除，或者社区的配置还没有加载
+		for i := range tasks {
+			err := s.ScanNow(tasks[i].Id)
+			if err != nil && !errors.Is(err, ErrTaskNotFound) && !errors.Is(err, ErrCommunityNotFound) {
+				t.Error(err)
+			}
+		}
+	})
+
+	wg.Wait()
+
+	s.platform.SetScanConfig(configs[1], "sha-final")
+	s.GenerateTask()
+	s.ExecTask()
+
+	for _, image := range []string{testImage, extraImage} {
+		task := s.findTask(t, image)
+		s.waitSucceeded(t, &task)
+	}
+
+	s.Shutdown(context.Background())
+
+	tasks, err := s.tasks.FindAll(testCommunity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tasks) != 2 {
+		t.Fatalf("got %d tasks, want 2: %+v", len(tasks), tasks)
+	}
+
+	for i := range tasks {
+		if tasks[i].Status != domain.TaskStatusSucceeded {
+			t.Errorf("task of %s: status %s, error %q", tasks[i].ImagePath(), tasks[i].Status, tasks[i].LastError)
+		}
+	}
+}
@@ -1,32 +1,73 @@
 package app
 
 import (
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"time"
+
 	"github.com/sirupsen/logrus"
 
+	"github.com/opensourceways/image-scanning/common/infrastructure/command"
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/message"
+	"github.com/opensourceways/image-scanning/scanning/domain/notifier"
+	"github.com/opensourceways/image-scanning/scanning/infrastructure/metrics"
 	"github.com/opensourceways/image-scanning/utils"
 )
 
 const (
 	script           = "./trivy_env.sh"
 	trivyResourceDir = "persistent/trivy_resource/"
+	trivyDBFile      = trivyResourceDir + "db/trivy.db"
+	trivyDBMetadata  = trivyResourceDir + "db/metadata.json"
 )
 
+// TrivyService ctx结束时（收到退出信号）终止正在进行的下载
 type TrivyService interface {
-	InitTrivyEnv() error
-	UpdateTrivyDB()
+	InitTrivyEnv(ctx context.Context) error
+	UpdateTrivyDB(ctx context.Context) error
 }
 
-func NewTrivyService(r *TrivyRepo) *trivyService {
-	return &trivyService{
-		repo: r,
+// NewTrivyService 每个副本各自更新本地的漏洞库，isLeader用于只由leader发送更新失败的通知
+func NewTrivyService(
+	r *TrivyRepo, n notifier.Notifier, p message.Publisher, runner command.Runner, timeout *Timeout,
+	isLeader func() bool,
+) *trivyService {
+	t := &trivyService{
+		runner:    runner,
+		timeout:   timeout,
+		repo:      r,
+		notifier:  n,
+		publisher: p,
+		isLeader:  isLeader,
 	}
+
+	metrics.RegisterGaugeFunc("trivy_db_age_seconds", "Seconds since the trivy db was last built.", nil,
+		func() float64 {
+			updatedAt, err := dbUpdatedAt()
+			if err != nil {
+				return math.NaN()
+			}
+
+			return time.Since(updatedAt).Seconds()
+		},
+	)
+
+	return t
 }
 
 type trivyService struct {
-	repo *TrivyRepo
+	runner    command.Runner
+	timeout   *Timeout
+	repo      *TrivyRepo
+	notifier  notifier.Notifier
+	publisher message.Publisher
+	isLeader  func() bool
 }
 
-func (t *trivyService) InitTrivyEnv() error {
+func (t *trivyService) InitTrivyEnv(ctx context.Context) error {
 	exist, err := utils.PathExists(trivyResourceDir)
 	if err != nil {
 		return err
@@ -36,17 +77,65 @@ func (t *trivyService) InitTrivyEnv() error {
 		return nil
 	}
 
-	out, err := utils.RunCmd(script, "init", trivyResourceDir, t.repo.Trivy, t.repo.TrivyDB, t.repo.VulnList)
+	out, err := t.runner.Run(ctx, command.Cmd{
+		Name:    script,
+		Args:    []string{"init", trivyResourceDir, t.repo.Trivy, t.repo.TrivyDB, t.repo.VulnList},
+		Timeout: utils.StringToDuration(t.timeout.TrivyInit),
+	})
 	if err != nil {
 		logrus.Errorf("init trivy env failed: %s,output: %s", err.Error(), out)
+
+		// 初始化中断后目录已经存在，删除后下次启动重新初始化
+		if rerr := os.RemoveAll(trivyResourceDir); rerr != nil {
+			logrus.Errorf("remove %s failed: %s", trivyResourceDir, rerr.Error())
+		}
+
 		return err
 	}
 
 	return nil
 }
 
-func (t *trivyService) UpdateTrivyDB() {
-	if out, err := utils.RunCmd(script, "update", trivyResourceDir); err != nil {
-		logrus.Errorf("update trivy db failed: %s,output: %s", err.Error(), out)
+func (t *trivyService) UpdateTrivyDB(ctx context.Context) error {
+	out, err := t.runner.Run(ctx, command.Cmd{
+		Name:    script,
+		Args:    []string{"update", trivyResourceDir},
+		Timeout: utils.StringToDuration(t.timeout.TrivyUpdate),
+	})
+	if err == nil {
+		publish(t.publisher, domain.NewTrivyDBUpdatedEvent())
+		return nil
+	}
+
+	logrus.Errorf("update trivy db failed: %s,output: %s", err.Error(), out)
+
+	// 退出时被取消不是更新失败；各副本同时从同一个源更新，失败原因一般相同，避免每个副本各发一次通知
+	if ctx.Err() != nil || !t.isLeader() {
+		return err
 	}
+
+	if nerr := t.notifier.Notify(domain.NewTrivyDBUpdateFailedEvent(err)); nerr != nil {
+		logrus.Errorf("notify trivy db update failure failed: %s", nerr.Error())
+	}
+
+	return err
+}
+
+// dbUpdatedAt 优先读取trivy-db生成的metadata.json，读取失败时使用数据库文件的修改时间
+func dbUpdatedAt() (time.Time, error) {
+	var metadata struct {
+		UpdatedAt time.Time `json:"UpdatedAt"`
+	}
+
+	data, err := os.ReadFile(trivyDBMetadata)
+	if err == nil && json.Unmarshal(data, &metadata) == nil && !metadata.UpdatedAt.IsZero() {
+		return metadata.UpdatedAt, nil
+	}
+
+	info, err := os.Stat(trivyDBFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return info.ModTime(), nil
 }
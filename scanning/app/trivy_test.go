@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/opensourceways/image-scanning/common/infrastructure/command"
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+// recordNotifier 记录发送的通知
+type recordNotifier struct {
+	mu     sync.Mutex
+	events []domain.Event
+}
+
+func (n *recordNotifier) Notify(e domain.Event) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.events = append(n.events, e)
+
+	return nil
+}
+
+func (n *recordNotifier) Events() []domain.Event {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append([]domain.Event(nil), n.events...)
+}
+
+func newTestTrivyService(runner command.Runner, n *recordNotifier, isLeader func() bool) *trivyService {
+	timeout := Timeout{}
+	timeout.SetDefault()
+
+	return NewTrivyService(&TrivyRepo{}, n, nil, runner, &timeout, isLeader)
+}
+
+func TestTrivyUpdateFailureNotifiedByLeaderOnly(t *testing.T) {
+	runner := newTestRunner(t)
+	runner.Fail("update", errors.New("download failed"))
+
+	for _, leader := range []bool{false, true} {
+		n := &recordNotifier{}
+		svc := newTestTrivyService(runner, n, func() bool { return leader })
+
+		if err := svc.UpdateTrivyDB(context.Background()); err == nil {
+			t.Fatal("update should fail")
+		}
+
+		events := n.Events()
+		if leader && (len(events) != 1 || events[0].Type != domain.EventTrivyDBUpdateFailed) {
+			t.Errorf("leader should notify the failure once, got %+v", events)
+		}
+
+		if !leader && len(events) != 0 {
+			t.Errorf("follower should not notify, got %+v", events)
+		}
+	}
+}
+
+func TestTrivyUpdateCanceledOnShutdown(t *testing.T) {
+	n := &recordNotifier{}
+	svc := newTestTrivyService(newTestRunner(t), n, func() bool { return true })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := svc.UpdateTrivyDB(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("want canceled, got %v", err)
+	}
+
+	if events := n.Events(); len(events) != 0 {
+		t.Errorf("canceled update should not be notified, got %+v", events)
+	}
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/platform"
+)
+
+// loadVex 加载扫描配置中的VEX文档，单个文档加载失败不影响其他文档
+func loadVex(plat platform.Platform, sources []domain.VexSource) domain.VexStatements {
+	var statements domain.VexStatements
+	for _, source := range sources {
+		data, err := readVexSource(plat, source)
+		if err != nil {
+			logrus.Errorf("read vex %s failed: %s", source, err.Error())
+			continue
+		}
+
+		s, err := domain.ParseVex(source, data)
+		if err != nil {
+			logrus.Errorf("parse vex %s failed: %s", source, err.Error())
+			continue
+		}
+
+		statements = append(statements, s...)
+	}
+
+	return statements
+}
+
+func readVexSource(plat platform.Platform, source domain.VexSource) ([]byte, error) {
+	if source.Path != "" {
+		return plat.DownloadConfigFile(source.Path)
+	}
+
+	return os.ReadFile(source.File) // #nosec G304
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/opensourceways/image-scanning/scanning/app"
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+const maxRequestBodySize = 1 << 20
+
+type adhocController struct {
+	service app.AdhocService
+}
+
+func addRouterForAdhocController(mux *http.ServeMux, s app.AdhocService) {
+	ctl := adhocController{service: s}
+
+	mux.HandleFunc("POST /api/v1/scans", ctl.submit)
+	mux.HandleFunc("POST /api/v1/scans/archive", ctl.submitArchive)
+	mux.HandleFunc("GET /api/v1/scans/{id}", ctl.get)
+}
+
+type reqToSubmitScan struct {
+	Image string   `json:"image"`
+	Arch  []string `json:"arch"`
+}
+
+// submit 扫描在后台执行，通过返回的id查询结果
+func (ctl *adhocController) submit(w http.ResponseWriter, r *http.Request) {
+	var req reqToSubmitScan
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize)).Decode(&req); err != nil {
+		sendBadRequest(w, "invalid request body")
+		return
+	}
+
+	scan, err := ctl.service.Submit(req.Image, req.Arch)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, responseData{Data: toAdhocScanDTO(&scan)})
+}
+
+// submitArchive 请求体为 docker save 或 skopeo copy oci-archive: 生成的tar包，
+// 通过format参数指定格式，默认为docker-archive
+func (ctl *adhocController) submitArchive(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = domain.ArchiveFormatDocker
+	}
+
+	scan, err := ctl.service.SubmitArchive(r.URL.Query().Get("name"), format, r.Body)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, responseData{Data: toAdhocScanDTO(&scan)})
+}
+
+func (ctl *adhocController) get(w http.ResponseWriter, r *http.Request) {
+	scan, err := ctl.service.Get(r.PathValue("id"))
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendSuccess(w, toAdhocScanDTO(&scan))
+}
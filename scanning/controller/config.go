@@ -0,0 +1,17 @@
+package controller
+
+import "errors"
+
+// Config 除指标和健康检查外，所有接口都需要在Authorization头中携带 Bearer Token，
+// 暂停、立即扫描、临时扫描等接口会修改任务或者拉取任意镜像，不允许关闭鉴权
+type Config struct {
+	Token string `json:"token"`
+}
+
+func (cfg *Config) Validate() error {
+	if cfg.Token == "" {
+		return errors.New("api token is required")
+	}
+
+	return nil
+}
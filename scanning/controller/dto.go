@@ -0,0 +1,215 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/opensourceways/image-scanning/scanning/app"
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+type taskDTO struct {
+	Id           int64        `json:"id"`
+	Community    string       `json:"community"`
+	Registry     string       `json:"registry"`
+	Namespace    string       `json:"namespace"`
+	Image        string       `json:"image"`
+	Tag          string       `json:"tag"`
+	Arch         []string     `json:"arch"`
+	Interval     int          `json:"interval"`
+	Cron         string       `json:"cron,omitempty"`
+	Priority     int          `json:"priority"`
+	Owner        domain.Owner `json:"owner"`
+	Paused       bool         `json:"paused"`
+	LastScanTime *time.Time   `json:"last_scan_time,omitempty"`
+	Status       string       `json:"status"`
+	LastError    string       `json:"last_error,omitempty"`
+	Failures     int          `json:"failures"`
+	RetryAt      *time.Time   `json:"retry_at,omitempty"`
+	NextScanTime *time.Time   `json:"next_scan_time,omitempty"`
+}
+
+func toTaskDTO(t *domain.Task) taskDTO {
+	dto := taskDTO{
+		Id:        t.Id,
+		Community: t.Community,
+		Namespace: t.Namespace,
+		Image:     t.Image,
+		Tag:       t.Tag,
+		Arch:      t.FormatArch(),
+		Interval:  t.Interval,
+		Cron:      t.Cron,
+		Priority:  t.Priority,
+		Owner:     t.Owner,
+		Paused:    t.Paused,
+		Status:    t.Status,
+		LastError: t.LastError,
+		Failures:  t.Failures,
+	}
+
+	if t.Registry != nil {
+		dto.Registry = t.Registry.String()
+	}
+
+	if !t.LastScanTime.IsZero() {
+		dto.LastScanTime = &t.LastScanTime
+	}
+
+	if !t.RetryAt.IsZero() {
+		dto.RetryAt = &t.RetryAt
+	}
+
+	if !t.NextScanTime.IsZero() {
+		dto.NextScanTime = &t.NextScanTime
+	}
+
+	return dto
+}
+
+type recordDTO struct {
+	Id        int64                `json:"id"`
+	TaskId    int64                `json:"task_id"`
+	Image     string               `json:"image"`
+	Owner     domain.Owner         `json:"owner"`
+	Policy    *domain.PolicyResult `json:"policy,omitempty"`
+	Arches    []domain.ArchRecord  `json:"arches"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+func toRecordDTO(r *domain.ScanRecord) recordDTO {
+	return recordDTO{
+		Id:        r.Id,
+		TaskId:    r.TaskId,
+		Image:     r.Image,
+		Owner:     r.Owner,
+		Policy:    r.Policy,
+		Arches:    r.Arches,
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+type communityDTO struct {
+	Name           string `json:"name"`
+	Paused         bool   `json:"paused"`
+	Weight         int    `json:"weight"`
+	MaxConcurrency int    `json:"max_concurrency"`
+	Running        int    `json:"running"`
+}
+
+func toCommunityDTO(c *app.CommunityStatus) communityDTO {
+	return communityDTO{
+		Name:           c.Name,
+		Paused:         c.Paused,
+		Weight:         c.Weight,
+		MaxConcurrency: c.MaxConcurrency,
+		Running:        c.Running,
+	}
+}
+
+type adhocScanDTO struct {
+	Id         string              `json:"id"`
+	Image      string              `json:"image"`
+	Arch       []string            `json:"arch"`
+	Status     string              `json:"status"`
+	Error      string              `json:"error,omitempty"`
+	Arches     []domain.ArchRecord `json:"arches,omitempty"`
+	Report     string              `json:"report,omitempty"`
+	CreatedAt  time.Time           `json:"created_at"`
+	FinishedAt *time.Time          `json:"finished_at,omitempty"`
+}
+
+func toAdhocScanDTO(s *domain.AdhocScan) adhocScanDTO {
+	dto := adhocScanDTO{
+		Id:        s.Id,
+		Image:     s.ImagePath(),
+		Arch:      s.Arch,
+		Status:    s.Status,
+		Error:     s.Error,
+		Arches:    s.Arches,
+		Report:    s.Report,
+		CreatedAt: s.CreatedAt,
+	}
+
+	if !s.FinishedAt.IsZero() {
+		dto.FinishedAt = &s.FinishedAt
+	}
+
+	return dto
+}
+
+type findingDTO struct {
+	TaskId           int64     `json:"task_id"`
+	Community        string    `json:"community"`
+	Image            string    `json:"image"`
+	Arch             string    `json:"arch"`
+	Digest           string    `json:"digest"`
+	VulnerabilityID  string    `json:"vulnerability_id"`
+	PkgName          string    `json:"pkg_name"`
+	InstalledVersion string    `json:"installed_version"`
+	FixedVersion     string    `json:"fixed_version"`
+	Fixable          bool      `json:"fixable"`
+	Severity         string    `json:"severity"`
+	ScannedAt        time.Time `json:"scanned_at"`
+}
+
+func toFindingDTO(f *domain.Finding) findingDTO {
+	return findingDTO{
+		TaskId:           f.TaskId,
+		Community:        f.Community,
+		Image:            f.Image,
+		Arch:             f.Arch,
+		Digest:           f.Digest,
+		VulnerabilityID:  f.VulnerabilityID,
+		PkgName:          f.PkgName,
+		InstalledVersion: f.InstalledVersion,
+		FixedVersion:     f.FixedVersion,
+		Fixable:          f.IsFixable(),
+		Severity:         f.Severity,
+		ScannedAt:        f.ScannedAt,
+	}
+}
+
+// runDTO 耗时的单位为毫秒
+type runDTO struct {
+	Id               int64             `json:"id"`
+	TaskId           int64             `json:"task_id"`
+	Community        string            `json:"community"`
+	Image            string            `json:"image"`
+	Worker           string            `json:"worker"`
+	StartedAt        time.Time         `json:"started_at"`
+	FinishedAt       time.Time         `json:"finished_at"`
+	Duration         int64             `json:"duration"`
+	PullDuration     int64             `json:"pull_duration"`
+	ScanDuration     int64             `json:"scan_duration"`
+	UploadDuration   int64             `json:"upload_duration"`
+	BytesPulled      int64             `json:"bytes_pulled"`
+	TrivyDBUpdatedAt *time.Time        `json:"trivy_db_updated_at,omitempty"`
+	Digests          map[string]string `json:"digests,omitempty"`
+	Outcome          string            `json:"outcome"`
+	Error            string            `json:"error,omitempty"`
+}
+
+func toRunDTO(r *domain.ScanRun) runDTO {
+	dto := runDTO{
+		Id:             r.Id,
+		TaskId:         r.TaskId,
+		Community:      r.Community,
+		Image:          r.Image,
+		Worker:         r.Worker,
+		StartedAt:      r.StartedAt,
+		FinishedAt:     r.FinishedAt,
+		Duration:       r.Duration().Milliseconds(),
+		PullDuration:   r.PullDuration.Milliseconds(),
+		ScanDuration:   r.ScanDuration.Milliseconds(),
+		UploadDuration: r.UploadDuration.Milliseconds(),
+		BytesPulled:    r.BytesPulled,
+		Digests:        r.Digests,
+		Outcome:        r.Outcome,
+		Error:          r.Error,
+	}
+
+	if !r.TrivyDBUpdatedAt.IsZero() {
+		dto.TrivyDBUpdatedAt = &r.TrivyDBUpdatedAt
+	}
+
+	return dto
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/opensourceways/image-scanning/scanning/app"
+)
+
+type findingController struct {
+	service app.FindingService
+}
+
+func addRouterForFindingController(mux *http.ServeMux, s app.FindingService) {
+	ctl := findingController{service: s}
+
+	mux.HandleFunc("GET /api/v1/findings", ctl.search)
+}
+
+// search 查询受漏洞影响的镜像，支持按 cve、package、version、severity、community、fixable 过滤，
+// version为以逗号分隔的版本约束，例如 >=1.1.1,<3.0.8，severity可以用逗号分隔多个级别
+func (ctl *findingController) search(w http.ResponseWriter, r *http.Request) {
+	page, size, err := parsePage(r)
+	if err != nil {
+		sendBadRequest(w, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+	q := app.FindingQuery{
+		Community: query.Get("community"),
+		Cve:       strings.TrimSpace(query.Get("cve")),
+		Package:   strings.TrimSpace(query.Get("package")),
+		Version:   query.Get("version"),
+		Page:      page,
+		Size:      size,
+	}
+
+	for _, v := range strings.Split(query.Get("severity"), ",") {
+		if v = strings.ToUpper(strings.TrimSpace(v)); v != "" {
+			q.Severities = append(q.Severities, v)
+		}
+	}
+
+	if v := query.Get("fixable"); v != "" {
+		fixable, err := strconv.ParseBool(v)
+		if err != nil {
+			sendBadRequest(w, "invalid fixable")
+			return
+		}
+
+		q.Fixable = &fixable
+	}
+
+	findings, total, err := ctl.service.Search(&q)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	items := make([]findingDTO, len(findings))
+	for i := range findings {
+		items[i] = toFindingDTO(&findings[i])
+	}
+
+	sendSuccess(w, pageData{Total: total, Page: page, Size: size, Items: items})
+}
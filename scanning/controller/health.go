@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/image-scanning/scanning/app"
+)
+
+func addRouterForHealthController(mux *http.ServeMux, s app.HealthService) {
+	ctl := healthController{s: s}
+
+	mux.HandleFunc("GET /livez", ctl.livez)
+	mux.HandleFunc("GET /readyz", ctl.readyz)
+}
+
+type healthController struct {
+	s app.HealthService
+}
+
+func (ctl *healthController) livez(w http.ResponseWriter, r *http.Request) {
+	writeHealthReport(w, ctl.s.Liveness(r.Context()))
+}
+
+func (ctl *healthController) readyz(w http.ResponseWriter, r *http.Request) {
+	writeHealthReport(w, ctl.s.Readiness(r.Context()))
+}
+
+// writeHealthReport 探针只关心状态码，检查失败时返回503，详情放在body中
+func writeHealthReport(w http.ResponseWriter, report app.HealthReport) {
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		logrus.Errorf("write health report failed: %s", err.Error())
+	}
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/image-scanning/scanning/app"
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	errorBadRequest   = "bad_request"
+	errorUnauthorized = "unauthorized"
+	errorNotFound     = "not_found"
+	errorUnavailable  = "unavailable"
+	errorTooLarge     = "too_large"
+	errorSystemError  = "system_error"
+)
+
+type responseData struct {
+	Code string      `json:"code"`
+	Msg  string      `json:"msg"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+type pageData struct {
+	Total int64       `json:"total"`
+	Page  int         `json:"page"`
+	Size  int         `json:"size"`
+	Items interface{} `json:"items"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, data responseData) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		logrus.Errorf("write response failed: %s", err.Error())
+	}
+}
+
+func sendSuccess(w http.ResponseWriter, data interface{}) {
+	writeJSON(w, http.StatusOK, responseData{Data: data})
+}
+
+func sendAccepted(w http.ResponseWriter) {
+	writeJSON(w, http.StatusAccepted, responseData{})
+}
+
+func sendBadRequest(w http.ResponseWriter, msg string) {
+	writeJSON(w, http.StatusBadRequest, responseData{Code: errorBadRequest, Msg: msg})
+}
+
+func sendError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidImageReference),
+		errors.Is(err, domain.ErrInvalidArch),
+		errors.Is(err, domain.ErrInvalidArchiveFormat),
+		errors.Is(err, domain.ErrInvalidVersionRange),
+		errors.Is(err, app.ErrEmptyFindingQuery),
+		errors.Is(err, app.ErrVersionNoPackage):
+		sendBadRequest(w, err.Error())
+	case errors.Is(err, app.ErrArchiveTooLarge):
+		writeJSON(w, http.StatusRequestEntityTooLarge, responseData{Code: errorTooLarge, Msg: err.Error()})
+	case errors.Is(err, app.ErrTaskNotFound),
+		errors.Is(err, app.ErrCommunityNotFound),
+		errors.Is(err, app.ErrResultNotFound),
+		errors.Is(err, app.ErrScanNotFound):
+		writeJSON(w, http.StatusNotFound, responseData{Code: errorNotFound, Msg: err.Error()})
+	case errors.Is(err, app.ErrScanQueueFull):
+		writeJSON(w, http.StatusServiceUnavailable, responseData{Code: errorUnavailable, Msg: err.Error()})
+	default:
+		logrus.Errorf("handle request failed: %s", err.Error())
+		writeJSON(w, http.StatusInternalServerError, responseData{Code: errorSystemError, Msg: "system error"})
+	}
+}
+
+func parseId(r *http.Request) (int64, error) {
+	return strconv.ParseInt(r.PathValue("id"), 10, 64)
+}
+
+// parsePage page从1开始，size默认20，最大100
+func parsePage(r *http.Request) (page, size int, err error) {
+	page, size = 1, defaultPageSize
+
+	if v := r.URL.Query().Get("page"); v != "" {
+		if page, err = strconv.Atoi(v); err != nil || page < 1 {
+			return 0, 0, errors.New("invalid page")
+		}
+	}
+
+	if v := r.URL.Query().Get("size"); v != "" {
+		if size, err = strconv.Atoi(v); err != nil || size < 1 || size > maxPageSize {
+			return 0, 0, errors.New("invalid size")
+		}
+	}
+
+	return page, size, nil
+}
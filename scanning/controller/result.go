@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/opensourceways/image-scanning/scanning/app"
+)
+
+type resultController struct {
+	service app.ResultService
+}
+
+func addRouterForResultController(mux *http.ServeMux, s app.ResultService) {
+	ctl := resultController{service: s}
+
+	mux.HandleFunc("GET /api/v1/tasks/{id}/results", ctl.list)
+	mux.HandleFunc("GET /api/v1/tasks/{id}/results/latest", ctl.latest)
+}
+
+// latest 可以通过arch参数只查看某个架构的结果
+func (ctl *resultController) latest(w http.ResponseWriter, r *http.Request) {
+	id, err := parseId(r)
+	if err != nil {
+		sendBadRequest(w, "invalid task id")
+		return
+	}
+
+	record, err := ctl.service.LatestResult(id, r.URL.Query().Get("arch"))
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendSuccess(w, toRecordDTO(&record))
+}
+
+func (ctl *resultController) list(w http.ResponseWriter, r *http.Request) {
+	id, err := parseId(r)
+	if err != nil {
+		sendBadRequest(w, "invalid task id")
+		return
+	}
+
+	page, size, err := parsePage(r)
+	if err != nil {
+		sendBadRequest(w, err.Error())
+		return
+	}
+
+	records, total, err := ctl.service.ListResults(id, r.URL.Query().Get("arch"), page, size)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	items := make([]recordDTO, len(records))
+	for i := range records {
+		items[i] = toRecordDTO(&records[i])
+	}
+
+	sendSuccess(w, pageData{Total: total, Page: page, Size: size, Items: items})
+}
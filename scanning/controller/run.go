@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/opensourceways/image-scanning/scanning/app"
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+)
+
+type runController struct {
+	service app.RunService
+}
+
+func addRouterForRunController(mux *http.ServeMux, s app.RunService) {
+	ctl := runController{service: s}
+
+	mux.HandleFunc("GET /api/v1/runs", ctl.list)
+	mux.HandleFunc("GET /api/v1/tasks/{id}/runs", ctl.listOfTask)
+}
+
+// list 支持按 task_id、community、outcome、from、to 过滤，from和to为RFC3339格式的开始时间范围
+func (ctl *runController) list(w http.ResponseWriter, r *http.Request) {
+	var taskId int64
+	if v := r.URL.Query().Get("task_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			sendBadRequest(w, "invalid task id")
+			return
+		}
+
+		taskId = id
+	}
+
+	ctl.listRuns(w, r, taskId)
+}
+
+func (ctl *runController) listOfTask(w http.ResponseWriter, r *http.Request) {
+	id, err := parseId(r)
+	if err != nil {
+		sendBadRequest(w, "invalid task id")
+		return
+	}
+
+	ctl.listRuns(w, r, id)
+}
+
+func (ctl *runController) listRuns(w http.ResponseWriter, r *http.Request, taskId int64) {
+	page, size, err := parsePage(r)
+	if err != nil {
+		sendBadRequest(w, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+	opt := repository.ScanRunListOption{
+		TaskId:    taskId,
+		Community: query.Get("community"),
+		Outcome:   query.Get("outcome"),
+		Page:      page,
+		Size:      size,
+	}
+
+	if opt.Outcome != "" && !slices.Contains(domain.ScanOutcomes, opt.Outcome) {
+		sendBadRequest(w, "invalid outcome")
+		return
+	}
+
+	if opt.From, err = parseTime(query.Get("from")); err != nil {
+		sendBadRequest(w, "invalid from")
+		return
+	}
+
+	if opt.To, err = parseTime(query.Get("to")); err != nil {
+		sendBadRequest(w, "invalid to")
+		return
+	}
+
+	runs, total, err := ctl.service.ListRuns(&opt)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	items := make([]runDTO, len(runs))
+	for i := range runs {
+		items[i] = toRunDTO(&runs[i])
+	}
+
+	sendSuccess(w, pageData{Total: total, Page: page, Size: size, Items: items})
+}
+
+// parseTime 为空时返回零值，表示不限制
+func parseTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, v)
+}
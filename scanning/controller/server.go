@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/image-scanning/scanning/app"
+	"github.com/opensourceways/image-scanning/scanning/infrastructure/metrics"
+)
+
+const (
+	readHeaderTimeout = 10 * time.Second
+	bearerPrefix      = "Bearer "
+)
+
+// ServerOptions Cert和Key都不为空时使用HTTPS
+type ServerOptions struct {
+	Port int
+	Cert string
+	Key  string
+}
+
+func (o *ServerOptions) enableTLS() bool {
+	return o.Cert != "" && o.Key != ""
+}
+
+type Services struct {
+	Task    app.TaskService
+	Result  app.ResultService
+	Adhoc   app.AdhocService
+	Finding app.FindingService
+	Run     app.RunService
+	Health  app.HealthService
+}
+
+// StartServer 在后台启动HTTP服务，返回的server用于退出时关闭服务
+func StartServer(opt ServerOptions, cfg *Config, s Services) *http.Server {
+	mux := http.NewServeMux()
+	addRouterForTaskController(mux, s.Task)
+	addRouterForResultController(mux, s.Result)
+	addRouterForAdhocController(mux, s.Adhoc)
+	addRouterForFindingController(mux, s.Finding)
+	addRouterForRunController(mux, s.Run)
+
+	// 指标和健康检查接口不需要鉴权
+	root := http.NewServeMux()
+	root.Handle("/metrics", metrics.Handler())
+	addRouterForHealthController(root, s.Health)
+	root.Handle("/", authenticate(cfg.Token, mux))
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", opt.Port),
+		Handler:           root,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	go func() {
+		var err error
+		if opt.enableTLS() {
+			err = server.ListenAndServeTLS(opt.Cert, opt.Key)
+		} else {
+			logrus.Warn("tls cert or key is not set, serve api over http")
+			err = server.ListenAndServe()
+		}
+
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logrus.Fatalf("start api server failed: %s", err.Error())
+		}
+	}()
+
+	return server
+}
+
+// StartHealthServer 探针使用的端口，根路径保持兼容，等同于存活检查
+func StartHealthServer(port int, s app.HealthService) *http.Server {
+	mux := http.NewServeMux()
+	addRouterForHealthController(mux, s)
+
+	ctl := healthController{s: s}
+	mux.HandleFunc("/", ctl.livez)
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logrus.Fatalf("start health server failed: %s", err.Error())
+		}
+	}()
+
+	return server
+}
+
+// authenticate token为空时拒绝所有请求，配置校验已经保证token不为空
+func authenticate(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if token == "" || !strings.HasPrefix(auth, bearerPrefix) ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, bearerPrefix)), []byte(token)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, responseData{Code: errorUnauthorized, Msg: "invalid token"})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
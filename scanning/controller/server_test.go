@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthenticate(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	cases := []struct {
+		name  string
+		token string
+		auth  string
+		want  int
+	}{
+		{"valid token", "secret", "Bearer secret", http.StatusOK},
+		{"wrong token", "secret", "Bearer other", http.StatusUnauthorized},
+		{"missing header", "secret", "", http.StatusUnauthorized},
+		{"empty token rejects everything", "", "Bearer ", http.StatusUnauthorized},
+		{"empty token without header", "", "", http.StatusUnauthorized},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/adhoc", nil)
+		if c.auth != "" {
+			r.Header.Set("Authorization", c.auth)
+		}
+
+		w := httptest.NewRecorder()
+		authenticate(c.token, ok).ServeHTTP(w, r)
+
+		if w.Code != c.want {
+			t.Errorf("%s: want %d, got %d", c.name, c.want, w.Code)
+		}
+	}
+}
+
+func TestConfigRequiresToken(t *testing.T) {
+	if err := (&Config{}).Validate(); err == nil {
+		t.Error("empty token should be rejected")
+	}
+
+	if err := (&Config{Token: "secret"}).Validate(); err != nil {
+		t.Error(err)
+	}
+}
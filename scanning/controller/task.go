@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/opensourceways/image-scanning/scanning/app"
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+)
+
+type taskController struct {
+	service app.TaskService
+}
+
+func addRouterForTaskController(mux *http.ServeMux, s app.TaskService) {
+	ctl := taskController{service: s}
+
+	mux.HandleFunc("GET /api/v1/tasks", ctl.list)
+	mux.HandleFunc("GET /api/v1/tasks/{id}", ctl.get)
+	mux.HandleFunc("POST /api/v1/tasks/{id}/scan", ctl.scan)
+	mux.HandleFunc("POST /api/v1/tasks/{id}/pause", ctl.pause)
+	mux.HandleFunc("POST /api/v1/tasks/{id}/resume", ctl.resume)
+
+	mux.HandleFunc("GET /api/v1/communities", ctl.listCommunities)
+	mux.HandleFunc("POST /api/v1/communities/{name}/pause", ctl.pauseCommunity)
+	mux.HandleFunc("POST /api/v1/communities/{name}/resume", ctl.resumeCommunity)
+}
+
+// list 支持按 community、registry、namespace、image、tag、paused、status 过滤
+func (ctl *taskController) list(w http.ResponseWriter, r *http.Request) {
+	page, size, err := parsePage(r)
+	if err != nil {
+		sendBadRequest(w, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+	opt := repository.TaskListOption{
+		Community: query.Get("community"),
+		Registry:  query.Get("registry"),
+		Namespace: query.Get("namespace"),
+		Image:     query.Get("image"),
+		Tag:       query.Get("tag"),
+		Status:    query.Get("status"),
+		Page:      page,
+		Size:      size,
+	}
+
+	if opt.Status != "" && !slices.Contains(domain.TaskStatuses, opt.Status) {
+		sendBadRequest(w, "invalid status")
+		return
+	}
+
+	if v := query.Get("paused"); v != "" {
+		paused, err := strconv.ParseBool(v)
+		if err != nil {
+			sendBadRequest(w, "invalid paused")
+			return
+		}
+
+		opt.Paused = &paused
+	}
+
+	tasks, total, err := ctl.service.ListTasks(&opt)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	items := make([]taskDTO, len(tasks))
+	for i := range tasks {
+		items[i] = toTaskDTO(&tasks[i])
+	}
+
+	sendSuccess(w, pageData{Total: total, Page: page, Size: size, Items: items})
+}
+
+func (ctl *taskController) get(w http.ResponseWriter, r *http.Request) {
+	id, err := parseId(r)
+	if err != nil {
+		sendBadRequest(w, "invalid task id")
+		return
+	}
+
+	task, err := ctl.service.GetTask(id)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendSuccess(w, toTaskDTO(&task))
+}
+
+func (ctl *taskController) scan(w http.ResponseWriter, r *http.Request) {
+	ctl.handleTask(w, r, ctl.service.ScanNow, true)
+}
+
+func (ctl *taskController) pause(w http.ResponseWriter, r *http.Request) {
+	ctl.handleTask(w, r, ctl.service.PauseTask, false)
+}
+
+func (ctl *taskController) resume(w http.ResponseWriter, r *http.Request) {
+	ctl.handleTask(w, r, ctl.service.ResumeTask, false)
+}
+
+func (ctl *taskController) handleTask(
+	w http.ResponseWriter, r *http.Request, handle func(int64) error, async bool,
+) {
+	id, err := parseId(r)
+	if err != nil {
+		sendBadRequest(w, "invalid task id")
+		return
+	}
+
+	if err = handle(id); err != nil {
+		sendError(w, err)
+		return
+	}
+
+	if async {
+		sendAccepted(w)
+	} else {
+		sendSuccess(w, nil)
+	}
+}
+
+func (ctl *taskController) listCommunities(w http.ResponseWriter, r *http.Request) {
+	communities, err := ctl.service.ListCommunities()
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	items := make([]communityDTO, len(communities))
+	for i := range communities {
+		items[i] = toCommunityDTO(&communities[i])
+	}
+
+	sendSuccess(w, items)
+}
+
+func (ctl *taskController) pauseCommunity(w http.ResponseWriter, r *http.Request) {
+	if err := ctl.service.PauseCommunity(r.PathValue("name")); err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendSuccess(w, nil)
+}
+
+func (ctl *taskController) resumeCommunity(w http.ResponseWriter, r *http.Request) {
+	if err := ctl.service.ResumeCommunity(r.PathValue("name")); err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendSuccess(w, nil)
+}
@@ -0,0 +1,184 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/opensourceways/image-scanning/scanning/domain/primitive"
+)
+
+const (
+	AdhocImagesDir = "persistent/adhoc"
+
+	ArchiveFormatOCI    = "oci-archive"
+	ArchiveFormatDocker = "docker-archive"
+
+	// archiveArch 上传的镜像包只有一个架构，扫描前无法得知，扫描后使用镜像配置中的架构
+	archiveArch = "archive"
+
+	AdhocStatusQueued    = "queued"
+	AdhocStatusRunning   = "running"
+	AdhocStatusSucceeded = "succeeded"
+	AdhocStatusFailed    = "failed"
+)
+
+var (
+	ErrInvalidImageReference = errors.New("invalid image reference, expect registry/namespace/image:tag")
+	ErrInvalidArch           = errors.New("invalid arch")
+	ErrInvalidArchiveFormat  = errors.New("invalid archive format, expect oci-archive or docker-archive")
+
+	adhocArches = []string{"amd64", "arm64", "arm", "386", "ppc64le", "s390x", "riscv64", "loong64"}
+)
+
+// AdhocScan 临时扫描任务，不关联社区，也不上传报告，保存在数据库中供所有副本查询。
+// ArchiveFormat不为空时扫描的是上传的镜像包，Image为上传时指定的名称，
+// 镜像包只保存在接收上传的实例上，Node为该实例的标识
+type AdhocScan struct {
+	Id            string
+	Registry      primitive.Registry
+	Namespace     string
+	Image         string
+	Tag           string
+	ArchiveFormat string
+	Node          string
+	Arch          []string
+	Status        string
+	Error         string
+	Arches        []ArchRecord
+	Report        string
+	CreatedAt     time.Time
+	FinishedAt    time.Time
+}
+
+// NewAdhocScan reference格式为 registry/namespace/image:tag，namespace可以包含多级路径
+func NewAdhocScan(id, reference string, arches []string) (AdhocScan, error) {
+	name, tag, ok := strings.Cut(strings.TrimSpace(reference), ":")
+	if !ok || tag == "" || strings.ContainsAny(tag, "/@ ") {
+		return AdhocScan{}, ErrInvalidImageReference
+	}
+
+	parts := strings.Split(name, "/")
+	if len(parts) < 3 || slices.Contains(parts, "") {
+		return AdhocScan{}, ErrInvalidImageReference
+	}
+
+	registry, err := primitive.NewRegistry(parts[0])
+	if err != nil {
+		return AdhocScan{}, fmt.Errorf("%w: %s", ErrInvalidImageReference, err.Error())
+	}
+
+	if len(arches) == 0 {
+		return AdhocScan{}, ErrInvalidArch
+	}
+
+	formatArch := make([]string, 0, len(arches))
+	for _, arch := range arches {
+		arch = strings.TrimPrefix(strings.TrimSpace(arch), "linux/")
+		if !slices.Contains(adhocArches, arch) {
+			return AdhocScan{}, fmt.Errorf("%w: %s", ErrInvalidArch, arch)
+		}
+
+		if !slices.Contains(formatArch, arch) {
+			formatArch = append(formatArch, arch)
+		}
+	}
+
+	return AdhocScan{
+		Id:        id,
+		Registry:  registry,
+		Namespace: strings.Join(parts[1:len(parts)-1], "/"),
+		Image:     parts[len(parts)-1],
+		Tag:       tag,
+		Arch:      formatArch,
+		Status:    AdhocStatusQueued,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// NewArchiveScan format为oci-archive或docker-archive，name只用于展示，node为接收上传的实例
+func NewArchiveScan(id, name, format, node string) (AdhocScan, error) {
+	if format != ArchiveFormatOCI && format != ArchiveFormatDocker {
+		return AdhocScan{}, ErrInvalidArchiveFormat
+	}
+
+	if name = strings.TrimSpace(name); name == "" {
+		name = id
+	}
+
+	return AdhocScan{
+		Id:            id,
+		Image:         name,
+		ArchiveFormat: format,
+		Node:          node,
+		Arch:          []string{archiveArch},
+		Status:        AdhocStatusQueued,
+		CreatedAt:     time.Now(),
+	}, nil
+}
+
+func (s *AdhocScan) IsArchive() bool {
+	return s.ArchiveFormat != ""
+}
+
+func (s *AdhocScan) ImagePath() string {
+	if s.IsArchive() {
+		return fmt.Sprintf("%s:%s", s.ArchiveFormat, s.Image)
+	}
+
+	return fmt.Sprintf("%s/%s/%s:%s", s.Registry, s.Namespace, s.Image, s.Tag)
+}
+
+func (s *AdhocScan) FormatArch() []string {
+	return s.Arch
+}
+
+// LocalImagePath 使用独立的目录，避免与定时任务同时下载同一个镜像
+func (s *AdhocScan) LocalImagePath(arch string) string {
+	if s.IsArchive() {
+		return fmt.Sprintf("%s/%s.tar", AdhocImagesDir, s.Id)
+	}
+
+	return fmt.Sprintf("%s/%s_%s", AdhocImagesDir, s.Id, arch)
+}
+
+func (s *AdhocScan) IsFinished() bool {
+	return s.Status == AdhocStatusSucceeded || s.Status == AdhocStatusFailed
+}
+
+func (s *AdhocScan) Start() {
+	s.Status = AdhocStatusRunning
+}
+
+// Finish 任何架构扫描失败都视为失败，已完成架构的结果仍然保留
+func (s *AdhocScan) Finish(ars map[string]ArchResult, err error) {
+	s.FinishedAt = time.Now()
+	s.Status = AdhocStatusSucceeded
+
+	if ars != nil {
+		if s.IsArchive() {
+			ars = renameArchiveArch(ars)
+		}
+
+		s.Arches = newArchRecords(ars)
+		s.Report = BuildContent(ars, Owner{}, nil)
+	}
+
+	if err != nil {
+		s.Status = AdhocStatusFailed
+		s.Error = err.Error()
+	}
+}
+
+func renameArchiveArch(ars map[string]ArchResult) map[string]ArchResult {
+	ar, ok := ars[archiveArch]
+	if !ok || ar.Err != nil || ar.ScanResult.Metadata.ImageConfig.Arch == "" {
+		return ars
+	}
+
+	return map[string]ArchResult{
+		ar.ScanResult.Metadata.ImageConfig.Arch: ar,
+	}
+}
@@ -6,10 +6,27 @@ const (
 )
 
 type Community struct {
-	Name               string   `json:"name"                 required:"true"`
-	Token              string   `json:"token"                required:"true"`
-	Platform           string   `json:"platform"             required:"true"`
-	ScanConfigLocation Location `json:"scan_config_location" required:"true"`
+	Name               string       `json:"name"                 required:"true"`
+	Token              string       `json:"token"                required:"true"`
+	Platform           string       `json:"platform"             required:"true"`
+	ScanConfigLocation Location     `json:"scan_config_location" required:"true"`
+	Notification       Notification `json:"notification"`
+	Scheduling         Scheduling   `json:"scheduling"`
+}
+
+// Scheduling 社区之间按Weight加权公平地分配worker，MaxConcurrency限制社区在所有副本上
+// 同时运行的扫描数，为0时不限制
+type Scheduling struct {
+	Weight         int `json:"weight"`
+	MaxConcurrency int `json:"max_concurrency"`
+}
+
+func (s *Scheduling) GetWeight() int {
+	if s.Weight <= 0 {
+		return 1
+	}
+
+	return s.Weight
 }
 
 type Location struct {
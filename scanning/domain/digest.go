@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	DigestPeriodDaily  = "daily"
+	DigestPeriodWeekly = "weekly"
+)
+
+// EmailDigest 定期通过邮件发送扫描结果汇总，端口为465时使用SSL，否则在服务端支持时使用STARTTLS
+type EmailDigest struct {
+	Host     string   `json:"host"     required:"true"`
+	Port     int      `json:"port"     required:"true"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	From     string   `json:"from"     required:"true"`
+	To       []string `json:"to"       required:"true"`
+	Period   string   `json:"period"`
+}
+
+func (e *EmailDigest) GetPeriod() string {
+	if e.Period == "" {
+		return DigestPeriodDaily
+	}
+
+	return e.Period
+}
+
+// Duration 汇总的时间范围
+func (e *EmailDigest) Duration() time.Duration {
+	if e.GetPeriod() == DigestPeriodWeekly {
+		return 7 * 24 * time.Hour
+	}
+
+	return 24 * time.Hour
+}
+
+type DigestFinding struct {
+	Image         string
+	Arch          string
+	Vulnerability Vulnerability
+}
+
+type DigestImage struct {
+	Image   string
+	Details []string
+}
+
+// Digest 一个周期内扫描结果的变化，只统计周期内扫描过的镜像
+type Digest struct {
+	Community        string
+	Since            time.Time
+	Until            time.Time
+	ScannedImages    int
+	NewFindings      []DigestFinding
+	FixedFindings    []DigestFinding
+	FailingImages    []DigestImage
+	PolicyViolations []DigestImage
+}
+
+// NewDigest current为周期内每个任务最新的扫描结果，baseline为周期开始前每个任务最新的扫描结果
+func NewDigest(community string, since, until time.Time, current, baseline []ScanRecord) Digest {
+	d := Digest{
+		Community:     community,
+		Since:         since,
+		Until:         until,
+		ScannedImages: len(current),
+	}
+
+	previous := make(map[int64]*ScanRecord, len(baseline))
+	for i := range baseline {
+		previous[baseline[i].TaskId] = &baseline[i]
+	}
+
+	for i := range current {
+		record := &current[i]
+
+		if record.HasError() {
+			d.FailingImages = append(d.FailingImages, DigestImage{
+				Image:   record.Image,
+				Details: record.errors(),
+			})
+
+			continue
+		}
+
+		if record.IsPolicyFailed() {
+			d.PolicyViolations = append(d.PolicyViolations, DigestImage{
+				Image:   record.Image,
+				Details: record.Policy.Violations,
+			})
+		}
+
+		old, ok := previous[record.TaskId]
+		if !ok {
+			d.NewFindings = append(d.NewFindings, diffFindings(record, nil)...)
+			continue
+		}
+
+		if old.HasError() {
+			continue
+		}
+
+		d.NewFindings = append(d.NewFindings, diffFindings(record, old)...)
+		d.FixedFindings = append(d.FixedFindings, diffFindings(old, record)...)
+	}
+
+	return d
+}
+
+func (d *Digest) IsEmpty() bool {
+	return len(d.NewFindings) == 0 && len(d.FixedFindings) == 0 &&
+		len(d.FailingImages) == 0 && len(d.PolicyViolations) == 0
+}
+
+// diffFindings 在record中存在而在other中不存在的漏洞
+func diffFindings(record, other *ScanRecord) []DigestFinding {
+	existed := make(map[string]bool)
+	if other != nil {
+		for _, arch := range other.Arches {
+			for _, v := range arch.Vulnerabilities {
+				existed[arch.Arch+"/"+v.VulnerabilityID+"/"+v.PkgName] = true
+			}
+		}
+	}
+
+	var findings []DigestFinding
+	for _, arch := range record.Arches {
+		for _, v := range arch.Vulnerabilities {
+			if existed[arch.Arch+"/"+v.VulnerabilityID+"/"+v.PkgName] {
+				continue
+			}
+
+			findings = append(findings, DigestFinding{
+				Image:         record.Image,
+				Arch:          arch.Arch,
+				Vulnerability: v,
+			})
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].Vulnerability.VulnerabilityID < findings[j].Vulnerability.VulnerabilityID
+	})
+
+	return findings
+}
+
+func (r *ScanRecord) errors() []string {
+	var errs []string
+	for _, arch := range r.Arches {
+		if arch.Error != "" {
+			errs = append(errs, arch.Arch+": "+strings.TrimSpace(arch.Error))
+		}
+	}
+
+	return errs
+}
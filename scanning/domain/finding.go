@@ -0,0 +1,77 @@
+package domain
+
+import "time"
+
+// Finding 每个任务最近一次扫描中每个架构的漏洞，用于按漏洞、软件包查询受影响的镜像
+type Finding struct {
+	TaskId           int64
+	RecordId         int64
+	Community        string
+	Image            string
+	Arch             string
+	Digest           string
+	VulnerabilityID  string
+	PkgName          string
+	InstalledVersion string
+	FixedVersion     string
+	Severity         string
+	ScannedAt        time.Time
+}
+
+func (f *Finding) IsFixable() bool {
+	return f.FixedVersion != ""
+}
+
+// Findings 扫描失败的架构没有结果，不在其中
+func (r *ScanRecord) Findings() []Finding {
+	var findings []Finding
+	for _, arch := range r.Arches {
+		if arch.Error != "" {
+			continue
+		}
+
+		existed := make(map[string]bool)
+		for _, v := range arch.Vulnerabilities {
+			key := v.VulnerabilityID + "/" + v.PkgName + "/" + v.InstalledVersion
+			if existed[key] {
+				continue
+			}
+			existed[key] = true
+
+			findings = append(findings, Finding{
+				TaskId:           r.TaskId,
+				RecordId:         r.Id,
+				Community:        r.Community,
+				Image:            r.Image,
+				Arch:             arch.Arch,
+				Digest:           arch.Digest,
+				VulnerabilityID:  v.VulnerabilityID,
+				PkgName:          v.PkgName,
+				InstalledVersion: v.InstalledVersion,
+				FixedVersion:     v.FixedVersion,
+				Severity:         v.Severity,
+				ScannedAt:        r.CreatedAt,
+			})
+		}
+	}
+
+	return findings
+}
+
+// FailedArches 扫描失败的架构保留上一次的结果
+func (r *ScanRecord) FailedArches() []string {
+	var arches []string
+	for _, arch := range r.Arches {
+		if arch.Error != "" {
+			arches = append(arches, arch.Arch)
+		}
+	}
+
+	return arches
+}
+
+type SeverityCount struct {
+	Community string
+	Severity  string
+	Count     int64
+}
@@ -0,0 +1,200 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	IssueModeImage = "image"
+	IssueModeCve   = "cve"
+
+	IssueStateOpen   = "open"
+	IssueStateClosed = "closed"
+)
+
+// IssueConfig 未通过策略检查时自动在Repo中创建issue，Mode为image时每个镜像一个issue，为cve时每个漏洞一个issue
+type IssueConfig struct {
+	Repo       string   `json:"repo"`
+	Mode       string   `json:"mode"`
+	Severities []string `json:"severities"`
+	Labels     []string `json:"labels"`
+	Assignees  []string `json:"assignees"`
+}
+
+func (c *IssueConfig) IsEnabled() bool {
+	return c.Repo != ""
+}
+
+func (c *IssueConfig) IsCveMode() bool {
+	return c.Mode == IssueModeCve
+}
+
+// TrackedSeverity cve模式下只为这些级别的漏洞创建issue，默认只处理CRITICAL
+func (c *IssueConfig) TrackedSeverity(severity string) bool {
+	if len(c.Severities) == 0 {
+		return severity == SeverityCritical
+	}
+
+	return slices.Contains(c.Severities, severity)
+}
+
+type IssueContent struct {
+	Title     string
+	Body      string
+	Labels    []string
+	Assignees []string
+}
+
+// Digest 内容没有变化时不需要更新issue
+func (c *IssueContent) Digest() string {
+	h := sha256.New()
+	for _, v := range []string{c.Title, c.Body, strings.Join(c.Labels, ","), strings.Join(c.Assignees, ",")} {
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Issue 已创建的issue，用于去重以及在漏洞修复后关闭issue，Number为空时issue还没有创建，
+// ContentDigest为最近一次写入issue的内容摘要
+type Issue struct {
+	Id            int64
+	Community     string
+	Key           string
+	Repo          string
+	Number        string
+	State         string
+	Images        []string
+	Owners        map[string][]string
+	ContentDigest string
+}
+
+func (i *Issue) IsOpen() bool {
+	return i.State == IssueStateOpen
+}
+
+func (i *Issue) HasImage(image string) bool {
+	return slices.Contains(i.Images, image)
+}
+
+// AddImage 记录受影响的镜像以及镜像的负责人
+func (i *Issue) AddImage(image string, maintainers []string) {
+	if !i.HasImage(image) {
+		i.Images = append(i.Images, image)
+		sort.Strings(i.Images)
+	}
+
+	if i.Owners == nil {
+		i.Owners = make(map[string][]string)
+	}
+
+	i.Owners[image] = maintainers
+}
+
+func (i *Issue) RemoveImage(image string) {
+	i.Images = slices.DeleteFunc(i.Images, func(v string) bool {
+		return v == image
+	})
+
+	delete(i.Owners, image)
+}
+
+func (i *Issue) maintainers() []string {
+	var maintainers []string
+	for _, image := range i.Images {
+		for _, m := range i.Owners[image] {
+			if !slices.Contains(maintainers, m) {
+				maintainers = append(maintainers, m)
+			}
+		}
+	}
+
+	return maintainers
+}
+
+// assignees 优先指派给镜像的负责人
+func (c *IssueConfig) assignees(maintainers []string) []string {
+	if len(maintainers) > 0 {
+		return maintainers
+	}
+
+	return c.Assignees
+}
+
+func (c *IssueConfig) ImageIssueContent(record *ScanRecord) IssueContent {
+	body := fmt.Sprintf("镜像 `%s` 未通过策略检查\n\n", record.Image)
+	body += record.Owner.ToMarkdown() + "\n"
+	for _, v := range record.Policy.Violations {
+		body += fmt.Sprintf("- %s\n", v)
+	}
+
+	for _, arch := range record.Arches {
+		var rows []string
+		for _, v := range arch.Vulnerabilities {
+			if v.Severity == SeverityCritical || v.Severity == SeverityHigh {
+				rows = append(rows, fmt.Sprintf("| %s | %s | %s | %s | %s |",
+					v.PkgName, v.VulnerabilityID, v.Severity, v.InstalledVersion, v.FixedVersion))
+			}
+		}
+
+		if len(rows) == 0 {
+			continue
+		}
+
+		body += fmt.Sprintf("\n### 扫描架构：%s\n", arch.Arch)
+		body += "| 软件包 | 漏洞ID | 严重级别 | 安装版本 | 修复版本 |\n| :----- | :----- | :----- | :----- | :----- |\n"
+		body += strings.Join(rows, "\n") + "\n"
+	}
+
+	return IssueContent{
+		Title:     fmt.Sprintf("【镜像扫描】%s 未通过策略检查", record.Image),
+		Body:      body,
+		Labels:    c.Labels,
+		Assignees: c.assignees(record.Owner.Maintainers),
+	}
+}
+
+func (c *IssueConfig) CveIssueContent(issue *Issue) IssueContent {
+	body := fmt.Sprintf("漏洞 %s 影响以下镜像：\n\n", issue.Key)
+	for _, image := range issue.Images {
+		owner := Owner{Maintainers: issue.Owners[image]}
+		body += strings.TrimSpace(fmt.Sprintf("- `%s` %s", image, owner.Mentions())) + "\n"
+	}
+
+	return IssueContent{
+		Title:     fmt.Sprintf("【镜像扫描】%s", issue.Key),
+		Body:      body,
+		Labels:    c.Labels,
+		Assignees: c.assignees(issue.maintainers()),
+	}
+}
+
+// TrackedCves 扫描结果中需要创建issue的漏洞
+func (r *ScanRecord) TrackedCves(cfg *IssueConfig) []string {
+	cves := make(map[string]bool)
+	for _, arch := range r.Arches {
+		for _, v := range arch.Vulnerabilities {
+			if cfg.TrackedSeverity(v.Severity) {
+				cves[v.VulnerabilityID] = true
+			}
+		}
+	}
+
+	result := make([]string, 0, len(cves))
+	for cve := range cves {
+		result = append(result, cve)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+func (r *ScanRecord) IsPolicyFailed() bool {
+	return r.Policy != nil && !r.Policy.IsPassed()
+}
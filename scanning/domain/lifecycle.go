@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"time"
+)
+
+const (
+	LifecycleTaskCreated    = "task.created"
+	LifecycleTaskDeleted    = "task.deleted"
+	LifecycleScanStarted    = "scan.started"
+	LifecycleScanCompleted  = "scan.completed"
+	LifecycleScanFailed     = "scan.failed"
+	LifecycleTrivyDBUpdated = "trivy_db.updated"
+)
+
+// LifecycleEvent 任务和扫描的生命周期事件，供其他服务订阅
+type LifecycleEvent struct {
+	Type    string
+	Subject string
+	Time    time.Time
+	Data    LifecycleData
+}
+
+type LifecycleData struct {
+	Community string           `json:"community,omitempty"`
+	TaskId    int64            `json:"task_id,omitempty"`
+	Image     string           `json:"image,omitempty"`
+	Arch      []string         `json:"arch,omitempty"`
+	Owner     *Owner           `json:"owner,omitempty"`
+	Policy    *PolicyResult    `json:"policy,omitempty"`
+	Findings  *FindingsSummary `json:"findings,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// FindingsSummary 按严重级别统计的漏洞数量，同一个漏洞在多个架构中出现只计算一次
+type FindingsSummary struct {
+	Total      int                     `json:"total"`
+	Fixable    int                     `json:"fixable"`
+	Suppressed int                     `json:"suppressed"`
+	Severities map[string]int          `json:"severities"`
+	Arches     map[string]ArchFindings `json:"arches"`
+}
+
+type ArchFindings struct {
+	Total      int            `json:"total"`
+	Severities map[string]int `json:"severities"`
+	Error      string         `json:"error,omitempty"`
+}
+
+func (r *ScanRecord) FindingsSummary() FindingsSummary {
+	summary := FindingsSummary{
+		Severities: make(map[string]int),
+		Arches:     make(map[string]ArchFindings, len(r.Arches)),
+	}
+
+	counted := make(map[string]bool)
+	suppressed := make(map[string]bool)
+	for _, arch := range r.Arches {
+		af := ArchFindings{
+			Total:      len(arch.Vulnerabilities),
+			Severities: make(map[string]int),
+			Error:      arch.Error,
+		}
+
+		for _, v := range arch.Vulnerabilities {
+			af.Severities[v.Severity]++
+
+			key := v.VulnerabilityID + "/" + v.PkgName
+			if counted[key] {
+				continue
+			}
+			counted[key] = true
+
+			summary.Total++
+			summary.Severities[v.Severity]++
+			if v.FixedVersion != "" {
+				summary.Fixable++
+			}
+		}
+
+		for _, v := range arch.Suppressed {
+			suppressed[v.Vulnerability.VulnerabilityID+"/"+v.Vulnerability.PkgName] = true
+		}
+
+		summary.Arches[arch.Arch] = af
+	}
+
+	summary.Suppressed = len(suppressed)
+
+	return summary
+}
+
+func newTaskLifecycleEvent(t string, task *Task) LifecycleEvent {
+	return LifecycleEvent{
+		Type:    t,
+		Subject: task.UniqueKey(),
+		Time:    time.Now(),
+		Data: LifecycleData{
+			Community: task.Community,
+			TaskId:    task.Id,
+			Image:     task.ImagePath(),
+			Arch:      task.FormatArch(),
+		},
+	}
+}
+
+func NewTaskCreatedEvent(task *Task) LifecycleEvent {
+	e := newTaskLifecycleEvent(LifecycleTaskCreated, task)
+	if !task.Owner.IsEmpty() {
+		e.Data.Owner = &task.Owner
+	}
+
+	return e
+}
+
+func NewTaskDeletedEvent(task *Task) LifecycleEvent {
+	return newTaskLifecycleEvent(LifecycleTaskDeleted, task)
+}
+
+func NewScanStartedEvent(task *Task) LifecycleEvent {
+	return newTaskLifecycleEvent(LifecycleScanStarted, task)
+}
+
+// NewScanFinishedEvent 扫描出错时为scan.failed，record为空说明在下载镜像阶段就失败了
+func NewScanFinishedEvent(task *Task, record *ScanRecord, err error) LifecycleEvent {
+	t := LifecycleScanCompleted
+	if err != nil {
+		t = LifecycleScanFailed
+	}
+
+	e := newTaskLifecycleEvent(t, task)
+	if err != nil {
+		e.Data.Error = err.Error()
+	}
+
+	if record != nil {
+		summary := record.FindingsSummary()
+		e.Data.Findings = &summary
+		e.Data.Policy = record.Policy
+		if !record.Owner.IsEmpty() {
+			e.Data.Owner = &record.Owner
+		}
+	}
+
+	return e
+}
+
+func NewTrivyDBUpdatedEvent() LifecycleEvent {
+	return LifecycleEvent{
+		Type:    LifecycleTrivyDBUpdated,
+		Subject: "trivy-db",
+		Time:    time.Now(),
+	}
+}
@@ -0,0 +1,7 @@
+package message
+
+import "github.com/opensourceways/image-scanning/scanning/domain"
+
+type Publisher interface {
+	Publish(event domain.LifecycleEvent) error
+}
@@ -0,0 +1,170 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+const (
+	WebhookTypeGeneric  = "generic"
+	WebhookTypeFeishu   = "feishu"
+	WebhookTypeDingTalk = "dingtalk"
+	WebhookTypeWeCom    = "wecom"
+	WebhookTypeSlack    = "slack"
+
+	EventNewFindings         = "new_findings"
+	EventScanFailed          = "scan_failed"
+	EventTaskQuarantined     = "task_quarantined"
+	EventTrivyDBUpdateFailed = "trivy_db_update_failed"
+
+	defaultFailureThreshold = 3
+)
+
+type Notification struct {
+	Webhooks         []Webhook    `json:"webhooks"`
+	FailureThreshold int          `json:"failure_threshold"`
+	Email            *EmailDigest `json:"email"`
+}
+
+// GetFailureThreshold 任务连续失败达到该次数时发送通知
+func (n *Notification) GetFailureThreshold() int {
+	if n.FailureThreshold <= 0 {
+		return defaultFailureThreshold
+	}
+
+	return n.FailureThreshold
+}
+
+// Webhook Events为空时订阅所有事件，Sigs不为空时只接收这些SIG负责的镜像的事件
+type Webhook struct {
+	Type   string   `json:"type"   required:"true"`
+	Url    string   `json:"url"    required:"true"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+	Sigs   []string `json:"sigs"`
+}
+
+func (w *Webhook) Subscribe(e *Event) bool {
+	if len(w.Events) > 0 && !slices.Contains(w.Events, e.Type) {
+		return false
+	}
+
+	// 与镜像无关的事件所有人都需要关注
+	if len(w.Sigs) == 0 || e.Image == "" {
+		return true
+	}
+
+	return slices.Contains(w.Sigs, e.Sig)
+}
+
+type Event struct {
+	Type      string    `json:"type"`
+	Community string    `json:"community,omitempty"`
+	Image     string    `json:"image,omitempty"`
+	Sig       string    `json:"sig,omitempty"`
+	Mentions  []string  `json:"mentions,omitempty"`
+	Summary   string    `json:"summary"`
+	Details   []string  `json:"details,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+func (e *Event) Title() string {
+	switch e.Type {
+	case EventNewFindings:
+		return "镜像扫描发现新的高危漏洞"
+	case EventScanFailed:
+		return "镜像扫描连续失败"
+	case EventTaskQuarantined:
+		return "镜像扫描任务已隔离"
+	case EventTrivyDBUpdateFailed:
+		return "漏洞库更新失败"
+	default:
+		return e.Type
+	}
+}
+
+func NewFindingsEvent(record *ScanRecord, vulns []Vulnerability) Event {
+	details := make([]string, 0, len(vulns))
+	for _, v := range vulns {
+		details = append(details, fmt.Sprintf("%s %s %s %s", v.Severity, v.VulnerabilityID, v.PkgName, v.InstalledVersion))
+	}
+
+	return Event{
+		Type:      EventNewFindings,
+		Community: record.Community,
+		Image:     record.Image,
+		Sig:       record.Owner.Sig,
+		Mentions:  record.Owner.Maintainers,
+		Summary:   fmt.Sprintf("%s 新增%d个CRITICAL/HIGH漏洞", record.Image, len(vulns)),
+		Details:   details,
+		Time:      time.Now(),
+	}
+}
+
+func NewScanFailedEvent(task *Task, failures int, err error) Event {
+	return Event{
+		Type:      EventScanFailed,
+		Community: task.Community,
+		Image:     task.ImagePath(),
+		Sig:       task.Owner.Sig,
+		Mentions:  task.Owner.Maintainers,
+		Summary:   fmt.Sprintf("%s 连续%d次扫描失败", task.ImagePath(), failures),
+		Details:   []string{err.Error()},
+		Time:      time.Now(),
+	}
+}
+
+func NewTaskQuarantinedEvent(task *Task) Event {
+	return Event{
+		Type:      EventTaskQuarantined,
+		Community: task.Community,
+		Image:     task.ImagePath(),
+		Sig:       task.Owner.Sig,
+		Mentions:  task.Owner.Maintainers,
+		Summary:   fmt.Sprintf("%s 连续%d次扫描失败，已停止自动扫描，修复后请手动触发扫描", task.ImagePath(), task.Failures),
+		Details:   []string{task.LastError},
+		Time:      time.Now(),
+	}
+}
+
+func NewTrivyDBUpdateFailedEvent(err error) Event {
+	return Event{
+		Type:    EventTrivyDBUpdateFailed,
+		Summary: "trivy漏洞库更新失败，扫描结果可能不是最新的",
+		Details: []string{err.Error()},
+		Time:    time.Now(),
+	}
+}
+
+// NewVulnerabilities 与上一次扫描结果相比新增的CRITICAL和HIGH漏洞，任意架构中出现过即视为已存在
+func (r *ScanRecord) NewVulnerabilities(previous *ScanRecord) []Vulnerability {
+	existed := make(map[string]bool)
+	for _, arch := range previous.Arches {
+		for _, v := range arch.Vulnerabilities {
+			existed[v.VulnerabilityID+"/"+v.PkgName] = true
+		}
+	}
+
+	var vulns []Vulnerability
+	for _, arch := range r.Arches {
+		for _, v := range arch.Vulnerabilities {
+			if v.Severity != SeverityCritical && v.Severity != SeverityHigh {
+				continue
+			}
+
+			key := v.VulnerabilityID + "/" + v.PkgName
+			if !existed[key] {
+				existed[key] = true
+				vulns = append(vulns, v)
+			}
+		}
+	}
+
+	sort.Slice(vulns, func(i, j int) bool {
+		return vulns[i].VulnerabilityID < vulns[j].VulnerabilityID
+	})
+
+	return vulns
+}
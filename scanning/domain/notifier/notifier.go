@@ -0,0 +1,11 @@
+package notifier
+
+import "github.com/opensourceways/image-scanning/scanning/domain"
+
+type Notifier interface {
+	Notify(event domain.Event) error
+}
+
+type DigestSender interface {
+	SendDigest(digest *domain.Digest) error
+}
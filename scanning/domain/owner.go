@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+const labelOfImageAuthors = "org.opencontainers.image.authors"
+
+// Owner 镜像的负责人，Maintainers为代码托管平台的账号
+type Owner struct {
+	Maintainers []string `json:"maintainers"`
+	Sig         string   `json:"sig"`
+	Contact     string   `json:"contact"`
+}
+
+func (o Owner) IsEmpty() bool {
+	return len(o.Maintainers) == 0 && o.Sig == "" && o.Contact == ""
+}
+
+// Merge 扫描配置中的负责人优先，缺失的部分使用other补充
+func (o Owner) Merge(other Owner) Owner {
+	if len(o.Maintainers) == 0 {
+		o.Maintainers = other.Maintainers
+	}
+
+	if o.Sig == "" {
+		o.Sig = other.Sig
+	}
+
+	if o.Contact == "" {
+		o.Contact = other.Contact
+	}
+
+	return o
+}
+
+func (o Owner) Mentions() string {
+	mentions := make([]string, 0, len(o.Maintainers))
+	for _, m := range o.Maintainers {
+		mentions = append(mentions, "@"+m)
+	}
+
+	return strings.Join(mentions, " ")
+}
+
+func (o Owner) ToMarkdown() string {
+	if o.IsEmpty() {
+		return ""
+	}
+
+	var content string
+	if len(o.Maintainers) > 0 {
+		content += fmt.Sprintf("- 负责人：%s\n", o.Mentions())
+	}
+
+	if o.Sig != "" {
+		content += fmt.Sprintf("- SIG：%s\n", o.Sig)
+	}
+
+	if o.Contact != "" {
+		content += fmt.Sprintf("- 联系方式：%s\n", o.Contact)
+	}
+
+	return content
+}
+
+// OwnerLabels 镜像中记录负责人信息的label，联系方式默认读取 org.opencontainers.image.authors
+type OwnerLabels struct {
+	Maintainers string `json:"maintainers"`
+	Sig         string `json:"sig"`
+	Contact     string `json:"contact"`
+}
+
+func (l OwnerLabels) parse(labels map[string]string) Owner {
+	var owner Owner
+	if l.Maintainers != "" {
+		for _, m := range strings.Split(labels[l.Maintainers], ",") {
+			if m = strings.TrimPrefix(strings.TrimSpace(m), "@"); m != "" {
+				owner.Maintainers = append(owner.Maintainers, m)
+			}
+		}
+	}
+
+	if l.Sig != "" {
+		owner.Sig = labels[l.Sig]
+	}
+
+	contactLabel := l.Contact
+	if contactLabel == "" {
+		contactLabel = labelOfImageAuthors
+	}
+	owner.Contact = labels[contactLabel]
+
+	return owner
+}
+
+// ResolveOwner 合并扫描配置和镜像label中的负责人信息
+func ResolveOwner(task *Task, ars map[string]ArchResult, labels OwnerLabels) Owner {
+	owner := task.Owner
+	for _, ar := range ars {
+		if ar.Err != nil {
+			continue
+		}
+
+		owner = owner.Merge(labels.parse(ar.ScanResult.Metadata.ImageConfig.Config.Labels))
+		if len(owner.Maintainers) > 0 && owner.Sig != "" && owner.Contact != "" {
+			break
+		}
+	}
+
+	return owner
+}
@@ -6,4 +6,8 @@ type Platform interface {
 	Upload(string, string) error
 	SetOutput(output domain.Output)
 	DownloadScanConfig() (domain.ScanConfig, string, error)
+	DownloadConfigFile(string) ([]byte, error)
+	CreateIssue(repo string, content domain.IssueContent) (string, error)
+	UpdateIssue(repo, number string, content domain.IssueContent) error
+	CloseIssue(repo, number string) error
 }
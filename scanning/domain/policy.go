@@ -0,0 +1,182 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	localutils "github.com/opensourceways/image-scanning/utils"
+)
+
+const (
+	PolicyStatusPassed = "passed"
+	PolicyStatusFailed = "failed"
+
+	SeverityCritical = "CRITICAL"
+	SeverityHigh     = "HIGH"
+)
+
+// Policy 镜像是否可以发布的判定规则，未配置的规则不参与判定
+type Policy struct {
+	NoFixableCritical bool   `json:"no_fixable_critical"`
+	MaxCritical       *int   `json:"max_critical"`
+	MaxHigh           *int   `json:"max_high"`
+	MaxUnfixedHighAge string `json:"max_unfixed_high_age"`
+}
+
+// Validate 扫描配置加载时校验，避免规则在判定时才发现错误而被跳过
+func (p *Policy) Validate() error {
+	if p.MaxUnfixedHighAge == "" {
+		return nil
+	}
+
+	if _, err := localutils.StringToInterval(p.MaxUnfixedHighAge); err != nil {
+		return fmt.Errorf("invalid max_unfixed_high_age %s: %w", p.MaxUnfixedHighAge, err)
+	}
+
+	return nil
+}
+
+func (p *Policy) IsEmpty() bool {
+	return !p.NoFixableCritical && p.MaxCritical == nil && p.MaxHigh == nil && p.MaxUnfixedHighAge == ""
+}
+
+type PolicyResult struct {
+	Status     string   `json:"status"`
+	Violations []string `json:"violations"`
+}
+
+func (r *PolicyResult) IsPassed() bool {
+	return r.Status == PolicyStatusPassed
+}
+
+func (r *PolicyResult) ToMarkdown() string {
+	if r.IsPassed() {
+		return "## 策略检查：通过\n"
+	}
+
+	content := "## 策略检查：不通过\n"
+	for _, v := range r.Violations {
+		content += fmt.Sprintf("- %s\n", v)
+	}
+
+	return content
+}
+
+// Evaluate 对镜像所有架构的扫描结果进行判定，同一个漏洞在多个架构中出现只计算一次，
+// 任何架构扫描失败都判定为不通过
+func (p *Policy) Evaluate(ars map[string]ArchResult) *PolicyResult {
+	if p.IsEmpty() {
+		return nil
+	}
+
+	arches := make([]string, 0, len(ars))
+	for arch := range ars {
+		arches = append(arches, arch)
+	}
+	sort.Strings(arches)
+
+	var violations []string
+	vulns := make(map[string]Vulnerability)
+	for _, arch := range arches {
+		ar := ars[arch]
+		if ar.Err != nil {
+			violations = append(violations, fmt.Sprintf("[scan_error] 架构%s扫描失败", arch))
+			continue
+		}
+
+		for _, v := range ar.ScanResult.validVulnerabilities() {
+			vulns[v.VulnerabilityID+"/"+v.PkgName] = v
+		}
+	}
+
+	violations = append(violations, p.check(vulns)...)
+
+	result := &PolicyResult{
+		Status:     PolicyStatusPassed,
+		Violations: violations,
+	}
+	if len(violations) > 0 {
+		result.Status = PolicyStatusFailed
+	}
+
+	return result
+}
+
+// check 同一个漏洞影响多个软件包时只计算一次
+func (p *Policy) check(vulns map[string]Vulnerability) []string {
+	fixableCritical := make(map[string]bool)
+	critical := make(map[string]bool)
+	high := make(map[string]bool)
+
+	var highs []Vulnerability
+	for _, v := range vulns {
+		switch v.Severity {
+		case SeverityCritical:
+			critical[v.VulnerabilityID] = true
+			if v.FixedVersion != "" {
+				fixableCritical[v.VulnerabilityID] = true
+			}
+		case SeverityHigh:
+			high[v.VulnerabilityID] = true
+			highs = append(highs, v)
+		}
+	}
+
+	var violations []string
+	if p.NoFixableCritical && len(fixableCritical) > 0 {
+		violations = append(violations, fmt.Sprintf("[no_fixable_critical] 存在%d个有修复版本的CRITICAL漏洞：%s",
+			len(fixableCritical), strings.Join(sortedKeys(fixableCritical), ", ")))
+	}
+
+	if p.MaxCritical != nil && len(critical) > *p.MaxCritical {
+		violations = append(violations, fmt.Sprintf("[max_critical] CRITICAL漏洞数量%d超过上限%d",
+			len(critical), *p.MaxCritical))
+	}
+
+	if p.MaxHigh != nil && len(high) > *p.MaxHigh {
+		violations = append(violations, fmt.Sprintf("[max_high] HIGH漏洞数量%d超过上限%d",
+			len(high), *p.MaxHigh))
+	}
+
+	if p.MaxUnfixedHighAge != "" {
+		if v := p.checkUnfixedHighAge(highs); v != "" {
+			violations = append(violations, v)
+		}
+	}
+
+	return violations
+}
+
+// checkUnfixedHighAge 镜像中仍然存在的HIGH漏洞，从公开时间算起不能超过规定的时长，
+// 时长在加载扫描配置时已经校验过
+func (p *Policy) checkUnfixedHighAge(highs []Vulnerability) string {
+	maxAge, _ := localutils.StringToInterval(p.MaxUnfixedHighAge)
+	deadline := time.Now().Add(-time.Second * time.Duration(maxAge))
+
+	expired := make(map[string]bool)
+	for _, v := range highs {
+		if v.PublishedDate != nil && v.PublishedDate.Before(deadline) {
+			expired[v.VulnerabilityID] = true
+		}
+	}
+
+	if len(expired) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("[max_unfixed_high_age] %d个HIGH漏洞公开超过%s仍未修复：%s",
+		len(expired), p.MaxUnfixedHighAge, strings.Join(sortedKeys(expired), ", "))
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPolicyCountsDistinctVulnerabilities(t *testing.T) {
+	maxCritical := 1
+	p := Policy{NoFixableCritical: true, MaxCritical: &maxCritical}
+
+	ar := ArchResult{}
+	ar.ScanResult.Results = []Result{{
+		Class: "os-pkgs",
+		Type:  osTypeOpenEuler,
+		Vulnerabilities: []Vulnerability{
+			{VulnerabilityID: "CVE-1", PkgName: "openssl", Severity: SeverityCritical, FixedVersion: "2"},
+			{VulnerabilityID: "CVE-1", PkgName: "openssl-libs", Severity: SeverityCritical, FixedVersion: "2"},
+		},
+	}}
+
+	result := p.Evaluate(map[string]ArchResult{"amd64": ar})
+	if result == nil || len(result.Violations) != 1 {
+		t.Fatalf("want only the fixable critical violation, got %+v", result)
+	}
+
+	if v := result.Violations[0]; !strings.Contains(v, "存在1个") || strings.Count(v, "CVE-1") != 1 {
+		t.Errorf("CVE-1 should be listed once: %s", v)
+	}
+}
+
+func TestPolicyUnfixedHighAge(t *testing.T) {
+	published := time.Now().Add(-48 * time.Hour)
+
+	ar := ArchResult{}
+	ar.ScanResult.Results = []Result{{
+		Class: "os-pkgs",
+		Type:  osTypeOpenEuler,
+		Vulnerabilities: []Vulnerability{
+			{VulnerabilityID: "CVE-2", PkgName: "a", Severity: SeverityHigh, PublishedDate: &published},
+			{VulnerabilityID: "CVE-2", PkgName: "b", Severity: SeverityHigh, PublishedDate: &published},
+		},
+	}}
+
+	p := Policy{MaxUnfixedHighAge: "1d"}
+	result := p.Evaluate(map[string]ArchResult{"amd64": ar})
+	if result == nil || len(result.Violations) != 1 || !strings.Contains(result.Violations[0], "1个HIGH漏洞") {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	for s, valid := range map[string]bool{"": true, "30d": true, "1d12h": true, "30 days": false, "x": false} {
+		p := Policy{MaxUnfixedHighAge: s}
+		if err := p.Validate(); (err == nil) != valid {
+			t.Errorf("Validate(%q) = %v, want valid %v", s, err, valid)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 任务优先级，优先级高的到期任务先扫描，要求立即扫描的任务不受优先级影响
+const (
+	PriorityLow    = -1
+	PriorityNormal = 0
+	PriorityHigh   = 1
+)
+
+var priorities = map[string]int{
+	"low":    PriorityLow,
+	"normal": PriorityNormal,
+	"high":   PriorityHigh,
+}
+
+func ParsePriority(s string) (int, error) {
+	p, ok := priorities[s]
+	if !ok {
+		return PriorityNormal, fmt.Errorf("invalid priority %s, must be one of low, normal, high", s)
+	}
+
+	return p, nil
+}
+
+// PriorityRule 镜像和tag都匹配时使用Priority，模式语法同path.Match，Images为空时匹配所有镜像，
+// 例如 tags: ["latest", "*-lts*"] 提高最新镜像和LTS镜像的优先级
+type PriorityRule struct {
+	Images   []string `json:"images"`
+	Tags     []string `json:"tags"`
+	Priority string   `json:"priority"`
+}
+
+func (r *PriorityRule) match(image, tag string) bool {
+	return (len(r.Images) == 0 || matchAny(r.Images, image)) && matchAny(r.Tags, tag)
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, s); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getPriority 镜像或tag上设置的优先级优先于global中的规则，规则按顺序匹配第一个，都没有时为normal
+func getPriority(global *Global, priority, image, tag string) int {
+	if priority != "" {
+		p, err := ParsePriority(priority)
+		if err != nil {
+			logrus.Errorf("priority of %s:%s: %s", image, tag, err.Error())
+		}
+
+		return p
+	}
+
+	if global == nil {
+		return PriorityNormal
+	}
+
+	for i := range global.Priorities {
+		rule := &global.Priorities[i]
+		if !rule.match(image, tag) {
+			continue
+		}
+
+		p, err := ParsePriority(rule.Priority)
+		if err != nil {
+			logrus.Errorf("priority rule of %s:%s: %s", image, tag, err.Error())
+		}
+
+		return p
+	}
+
+	return PriorityNormal
+}
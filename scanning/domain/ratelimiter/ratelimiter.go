@@ -0,0 +1,16 @@
+package ratelimiter
+
+import (
+	"context"
+	"net/http"
+)
+
+// RateLimiter 同一个镜像站的标签查询和镜像拉取共用限速和并发上限，被镜像站限速后暂停访问
+type RateLimiter interface {
+	// Acquire 等待镜像站的并发名额和请求配额，请求结束后调用release
+	Acquire(ctx context.Context, registry string) (release func(), err error)
+
+	// Observe 根据响应的状态码和Retry-After、RateLimit-Remaining等头部调整限速，
+	// 拿不到响应的请求（例如skopeo拉取镜像）header为空，被限速时按退避时间暂停
+	Observe(registry string, code int, header http.Header)
+}
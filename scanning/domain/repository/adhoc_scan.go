@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+// AdhocScan 临时扫描的队列和结果，排队中的扫描通过租约被一个worker独占，
+// 实例异常退出时租约过期，扫描会被其他实例重新领取；上传的镜像包只能由接收上传的实例领取
+type AdhocScan interface {
+	Add(scan *domain.AdhocScan) error
+	Find(id string) (domain.AdhocScan, error)
+	// CountQueued 所有实例中等待执行的扫描数
+	CountQueued() (int64, error)
+	Claim(owner, node string, lease time.Duration) ([]domain.AdhocScan, error)
+	RenewLease(id, owner string, lease time.Duration) error
+	Complete(scan *domain.AdhocScan, owner string) error
+	// FailQueued 实例退出前将只能由其执行的镜像包扫描标记为失败，返回这些扫描用于清理镜像包
+	FailQueued(node, reason string) ([]domain.AdhocScan, error)
+	// DeleteBefore 删除创建时间早于t的扫描
+	DeleteBefore(t time.Time) error
+}
@@ -0,0 +1,6 @@
+package repository
+
+type Community interface {
+	IsPaused(name string) (bool, error)
+	SetPaused(name string, paused bool) error
+}
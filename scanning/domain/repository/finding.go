@@ -0,0 +1,24 @@
+package repository
+
+import "github.com/opensourceways/image-scanning/scanning/domain"
+
+// FindingListOption 查询漏洞的过滤条件，为空的条件不参与过滤，Size不大于0时返回全部结果
+type FindingListOption struct {
+	Community       string
+	VulnerabilityID string
+	PkgName         string
+	Severities      []string
+	Fixable         *bool
+	Page            int
+	Size            int
+}
+
+type Finding interface {
+	// Replace 替换任务的扫描结果，keepArches中的架构保留原有的结果
+	Replace(taskId int64, keepArches []string, findings []domain.Finding) error
+	DeleteByTaskIds(ids []int64) error
+	List(opt *FindingListOption) (findings []domain.Finding, total int64, err error)
+	IsEmpty() (bool, error)
+	// CountBySeverity 每个社区各个严重级别的漏洞数量，同一个镜像的多个架构中的同一个漏洞只计算一次
+	CountBySeverity() ([]domain.SeverityCount, error)
+}
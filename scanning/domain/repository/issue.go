@@ -0,0 +1,12 @@
+package repository
+
+import "github.com/opensourceways/image-scanning/scanning/domain"
+
+type Issue interface {
+	Find(community, key string) (domain.Issue, error)
+	FindOpen(community string) ([]domain.Issue, error)
+
+	// Modify 锁定community和key对应的issue后调用modify，不存在时先插入一条没有编号的记录，
+	// 多个副本同时处理同一个issue时依次执行，modify返回true时保存修改
+	Modify(community, key string, modify func(issue *domain.Issue) bool) error
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+type ScanRecord interface {
+	Save(record *domain.ScanRecord) error
+	FindLatest(taskId int64) (domain.ScanRecord, error)
+	FindByTask(taskId int64, page, size int) (records []domain.ScanRecord, total int64, err error)
+	FindLatestPerTask(community string, from, to time.Time) ([]domain.ScanRecord, error)
+}
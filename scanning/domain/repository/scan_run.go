@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+// ScanRunListOption 查询执行历史的过滤条件，为空的条件不参与过滤，时间范围为[From, To)
+type ScanRunListOption struct {
+	TaskId    int64
+	Community string
+	Outcome   string
+	From      time.Time
+	To        time.Time
+	Page      int
+	Size      int
+}
+
+type ScanRun interface {
+	Add(run *domain.ScanRun) error
+	List(opt *ScanRunListOption) (runs []domain.ScanRun, total int64, err error)
+}
@@ -1,10 +1,57 @@
 package repository
 
-import "github.com/opensourceways/image-scanning/scanning/domain"
+import (
+	"errors"
+	"time"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+// ErrLeaseLost 租约已过期并被其他worker领取，或者任务已被删除
+var ErrLeaseLost = errors.New("task lease lost")
+
+// TaskListOption 查询任务的过滤条件，为空的条件不参与过滤
+type TaskListOption struct {
+	Community string
+	Registry  string
+	Namespace string
+	Image     string
+	Tag       string
+	Paused    *bool
+	Status    string
+	Page      int
+	Size      int
+}
+
+// ClaimOption 领取到期任务的条件，只领取Communities中的任务，租约到期前需要续约；
+// 按计划执行的扫描只领取Scheduled中的社区，要求立即扫描的任务不受限制
+type ClaimOption struct {
+	Owner       string
+	Communities []string
+	Scheduled   []string
+	Limit       int
+	Lease       time.Duration
+}
 
 type Task interface {
 	Save(task domain.Task) error
 	Find(task domain.Task) (domain.Task, error)
+	FindById(id int64) (domain.Task, error)
 	FindAll(name string) (tasks []domain.Task, err error)
+	List(opt *TaskListOption) (tasks []domain.Task, total int64, err error)
+	SetPaused(ids []int64, paused bool) error
+	Reschedule(id int64, next time.Time) error
 	DeleteByIds(ids []int64) error
+
+	// 任务队列：到期或者被要求立即扫描的任务通过租约被一个worker独占，
+	// worker异常退出时租约过期，任务会被重新领取
+	Claim(opt *ClaimOption) ([]domain.Task, error)
+	RenewLease(id int64, owner string, lease time.Duration) error
+	Complete(task domain.Task, owner string) error
+	Release(task domain.Task, owner string) error
+	SetStatus(id int64, owner, status string) error
+	RequestScan(id int64) error
+	CountDue(communities, scheduled []string) (int64, error)
+	// CountRunning 各社区持有未过期租约的任务数，即正在扫描的任务数
+	CountRunning(communities []string) (map[string]int, error)
 }
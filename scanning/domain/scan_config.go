@@ -1,17 +1,22 @@
 package domain
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"path"
 	"strings"
-	"time"
 
-	"github.com/opensourceways/server-common-lib/utils"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/opensourceways/image-scanning/scanning/domain/primitive"
+	"github.com/opensourceways/image-scanning/scanning/domain/ratelimiter"
 	localutils "github.com/opensourceways/image-scanning/utils"
 )
 
@@ -23,25 +28,26 @@ const (
 	apiToListTagsOfDocker = "https://hub.docker.com/v2/namespaces/%s/repositories/%s/tags?page_size=100"
 	//apiToListTagsOfQuay reference: https://docs.redhat.com/en/documentation/red_hat_quay/3.6/html-single/red_hat_quay_api_guide/index#listrepotags
 	apiToListTagsOfQuay = "https://quay.io//api/v1/repository/%s/%s/tag/?limit=100&page=%d"
-)
 
-var (
-	globalConfig map[string]*Global
+	maxRequestAttempts = 3
+	maxErrorBody       = 1024
 )
 
-func initGlobalConfig(community string, cfg *Global) {
-	if len(globalConfig) == 0 {
-		globalConfig = make(map[string]*Global)
-	}
+var tracer = otel.Tracer("github.com/opensourceways/image-scanning/scanning/domain")
 
-	globalConfig[community] = cfg
+type ScanConfig struct {
+	Version string      `json:"version"`
+	Scanner Scanner     `json:"scanner"`
+	Repos   []Repo      `json:"repos"`
+	Images  []Image     `json:"images"`
+	Vex     []VexSource `json:"vex"`
+	Policy  Policy      `json:"policy"`
+	Issue   IssueConfig `json:"issue"`
 }
 
-type ScanConfig struct {
-	Version string  `json:"version"`
-	Scanner Scanner `json:"scanner"`
-	Repos   []Repo  `json:"repos"`
-	Images  []Image `json:"images"`
+// Validate 校验加载后才能发现的错误，错误的配置不会替换正在使用的配置
+func (cfg *ScanConfig) Validate() error {
+	return cfg.Policy.Validate()
 }
 
 type Scanner struct {
@@ -52,7 +58,15 @@ type Global struct {
 	DefaultArches   []string `json:"default_arches"`
 	DefaultInterval string   `json:"default_interval"`
 
-	Output Output `json:"output"`
+	// Timezone 扫描窗口和没有指定CRON_TZ的cron表达式使用的时区
+	Timezone    string       `json:"timezone"`
+	ScanWindows []ScanWindow `json:"scan_windows"`
+	Blackouts   []Blackout   `json:"blackouts"`
+
+	Priorities []PriorityRule `json:"priorities"`
+
+	Output      Output      `json:"output"`
+	OwnerLabels OwnerLabels `json:"owner_labels"`
 }
 
 type Output struct {
@@ -66,16 +80,20 @@ type Repo struct {
 	Images    []string `json:"images"`
 	Arches    []string `json:"arches"`
 	Interval  string   `json:"interval"`
+	Priority  string   `json:"priority"`
+	Owner     Owner    `json:"owner"`
 }
 
 type Image struct {
 	Image string `json:"image"`
 	Tags  []Tag  `json:"tags"`
+	Owner Owner  `json:"owner"`
 }
 
 type Tag struct {
 	Tag      string   `json:"tag"`
 	Interval string   `json:"interval"`
+	Priority string   `json:"priority"`
 	Arches   []string `json:"arches"`
 	Disable  bool     `json:"disable"`
 }
@@ -84,54 +102,61 @@ func (o Output) GetRepoName() string {
 	return path.Base(o.Repo)
 }
 
-func (r Repo) genTask(communityName string, tasks map[string]Task) {
-	arch := getArches(communityName, r.Arches)
-	interval, err := getInterval(communityName, r.Interval)
+func (r Repo) genTask(
+	ctx context.Context, communityName string, global *Global, limiter ratelimiter.RateLimiter, tasks map[string]Task,
+) {
+	arch := getArches(global, r.Arches)
+	schedule, err := getSchedule(global, r.Interval)
 	if err != nil {
 		logrus.Errorf("get interval of %s failed: %s", r.Namespace, err.Error())
 		return
 	}
 
 	for _, image := range r.Images {
-		tags, err := r.AllTagsOfImage(image)
+		tags, err := r.AllTagsOfImage(ctx, limiter, image)
 		if err != nil {
 			logrus.Errorf("get all tags of %s/%s failed: %s", r.Namespace, image, err.Error())
 			continue
 		}
 
 		for _, tag := range tags {
-			task, err := ToTask(communityName, r.Registry, r.Namespace, image, tag, arch, interval)
+			task, err := ToTask(communityName, r.Registry, r.Namespace, image, tag, arch, schedule)
 			if err != nil {
 				logrus.Errorf("repo to task failed: %s", err.Error())
 				continue
 			}
 
+			task.Owner = r.Owner
+			task.Priority = getPriority(global, r.Priority, image, tag)
 			tasks[task.UniqueKey()] = task
 		}
 	}
 }
 
-func (i Image) genTask(communityName string, tasks map[string]Task) {
+func (i Image) genTask(communityName string, global *Global, tasks map[string]Task) {
 	for _, tag := range i.Tags {
-		task, err := tag.ToTask(communityName)
+		task, err := tag.ToTask(communityName, global)
 		if err != nil {
 			logrus.Errorf("tag %s to task failed: %s", tag.Tag, err.Error())
 			continue
 		}
 
+		task.Owner = i.Owner
+
 		// 当批量扫描任务和精准扫描任务发生重叠时，以精准的任务为高优先级，覆盖批量数据
 		tasks[task.UniqueKey()] = task
 	}
 }
 
-func (t Tag) ToTask(communityName string) (task Task, err error) {
+// ToTask 没有设置架构和扫描间隔时使用global中的默认值，优先级按global中的规则确定
+func (t Tag) ToTask(communityName string, global *Global) (task Task, err error) {
 	if t.Disable {
 		err = errors.New("tag is disabled")
 		return
 	}
 
-	arch := getArches(communityName, t.Arches)
-	interval, err := getInterval(communityName, t.Interval)
+	arch := getArches(global, t.Arches)
+	schedule, err := getSchedule(global, t.Interval)
 	if err != nil {
 		return
 	}
@@ -151,20 +176,96 @@ func (t Tag) ToTask(communityName string) (task Task, err error) {
 		return
 	}
 
-	return ToTask(communityName, registry, namespace, split2[0], split2[1], arch, interval)
+	task, err = ToTask(communityName, registry, namespace, split2[0], split2[1], arch, schedule)
+	if err != nil {
+		return
+	}
+
+	task.Priority = getPriority(global, t.Priority, task.Image, task.Tag)
+
+	return
 }
 
-func (r Repo) AllTagsOfImage(image string) ([]string, error) {
+func (r Repo) AllTagsOfImage(
+	ctx context.Context, limiter ratelimiter.RateLimiter, image string,
+) (tags []string, err error) {
+	ctx, span := tracer.Start(ctx, "ListTags", trace.WithAttributes(
+		attribute.String("registry", r.Registry),
+		attribute.String("namespace", r.Namespace),
+		attribute.String("image", image),
+	))
+	defer func() {
+		span.SetAttributes(attribute.Int("tags", len(tags)))
+		localutils.EndSpan(span, err)
+	}()
+
 	switch r.Registry {
 	case registryDocker:
-		return r.getTagsFromDocker(image)
+		return r.getTagsFromDocker(ctx, limiter, image)
 	case registryQuay:
-		return r.getTagsFromQuay(image)
+		return r.getTagsFromQuay(ctx, limiter, image)
 	default:
 		return nil, errors.New("unsupported registry")
 	}
 }
 
+// forwardTo 每次请求一个span，便于查看分页和限速的耗时；网络错误和被限速时重试，
+// 限速器按镜像站返回的Retry-After或者退避时间推迟下一次请求
+func forwardTo(
+	ctx context.Context, limiter ratelimiter.RateLimiter, registry, url string, result interface{},
+) (err error) {
+	ctx, span := tracer.Start(ctx, "HTTP GET", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("http.url", url)),
+	)
+	defer func() { localutils.EndSpan(span, err) }()
+
+	for attempt := 1; ; attempt++ {
+		var code int
+		code, err = get(ctx, limiter, registry, url, result)
+		span.SetAttributes(attribute.Int("http.status_code", code), attribute.Int("http.attempts", attempt))
+
+		retry := code == http.StatusTooManyRequests || (code == 0 && ctx.Err() == nil)
+		if err == nil || !retry || attempt >= maxRequestAttempts {
+			return err
+		}
+
+		logrus.Warnf("request %s failed, retry: %s", url, err.Error())
+	}
+}
+
+func get(
+	ctx context.Context, limiter ratelimiter.RateLimiter, registry, url string, result interface{},
+) (int, error) {
+	release, err := limiter.Acquire(ctx, registry)
+	if err != nil {
+		return 0, err
+	}
+
+	defer release()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	limiter.Observe(registry, resp.StatusCode, resp.Header)
+
+	if code := resp.StatusCode; code < 200 || code > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+		return code, fmt.Errorf("response has status:%s and body:%q", resp.Status, body)
+	}
+
+	return resp.StatusCode, json.NewDecoder(resp.Body).Decode(result)
+}
+
 type tagsResponseOfDocker struct {
 	Count   int    `json:"count"`
 	Next    string `json:"next"`
@@ -173,19 +274,13 @@ type tagsResponseOfDocker struct {
 	} `json:"results"`
 }
 
-func (r Repo) getTagsFromDocker(image string) ([]string, error) {
+func (r Repo) getTagsFromDocker(ctx context.Context, limiter ratelimiter.RateLimiter, image string) ([]string, error) {
 	url := fmt.Sprintf(apiToListTagsOfDocker, r.Namespace, image)
-	client := utils.NewHttpClient(3)
 
 	var tags []string
 	for {
-		req, err := http.NewRequest(http.MethodGet, url, nil)
-		if err != nil {
-			return nil, err
-		}
-
 		var resp tagsResponseOfDocker
-		if _, err = client.ForwardTo(req, &resp); err != nil {
+		if err := forwardTo(ctx, limiter, r.Registry, url, &resp); err != nil {
 			return nil, err
 		}
 
@@ -198,10 +293,6 @@ func (r Repo) getTagsFromDocker(image string) ([]string, error) {
 		} else {
 			url = resp.Next
 		}
-
-		// docker的api每分钟限速180次
-		// 这里设置大概每分钟访问120次
-		time.Sleep(time.Millisecond * 500)
 	}
 
 	return tags, nil
@@ -215,19 +306,14 @@ type tagsResponseOfQuay struct {
 	} `json:"tags"`
 }
 
-func (r Repo) getTagsFromQuay(image string) ([]string, error) {
+func (r Repo) getTagsFromQuay(ctx context.Context, limiter ratelimiter.RateLimiter, image string) ([]string, error) {
 	page := 1
-	client := utils.NewHttpClient(3)
 	var tags []string
 	for {
 		url := fmt.Sprintf(apiToListTagsOfQuay, r.Namespace, image, page)
-		req, err := http.NewRequest(http.MethodGet, url, nil)
-		if err != nil {
-			return nil, err
-		}
 
 		var resp tagsResponseOfQuay
-		if _, err = client.ForwardTo(req, &resp); err != nil {
+		if err := forwardTo(ctx, limiter, r.Registry, url, &resp); err != nil {
 			return nil, err
 		}
 
@@ -245,34 +331,33 @@ func (r Repo) getTagsFromQuay(image string) ([]string, error) {
 	return tags, nil
 }
 
-func getArches(community string, arches []string) []string {
+func getArches(global *Global, arches []string) []string {
 	if len(arches) != 0 {
 		return arches
 	}
 
-	global, ok := globalConfig[community]
-	if !ok {
+	if global == nil {
 		return nil
 	}
 
 	return global.DefaultArches
 }
 
-func getInterval(community, intervalString string) (int, error) {
-	interval, err := localutils.StringToInterval(intervalString)
-	if err == nil {
-		return interval, nil
+// getSchedule 没有设置或者格式错误时使用global中的默认值
+func getSchedule(global *Global, intervalString string) (Schedule, error) {
+	if global == nil {
+		return ParseSchedule(intervalString, "")
 	}
 
-	global, ok := globalConfig[community]
-	if !ok {
-		return 0, errors.New("no interval")
+	schedule, err := ParseSchedule(intervalString, global.Timezone)
+	if err == nil {
+		return schedule, nil
 	}
 
-	return localutils.StringToInterval(global.DefaultInterval)
+	return ParseSchedule(global.DefaultInterval, global.Timezone)
 }
 
-func ToTask(communityName, registry, namespace, image, tag string, arch []string, interval int) (Task, error) {
+func ToTask(communityName, registry, namespace, image, tag string, arch []string, schedule Schedule) (Task, error) {
 	pRegistry, err := primitive.NewRegistry(registry)
 	if err != nil {
 		return Task{}, err
@@ -285,6 +370,8 @@ func ToTask(communityName, registry, namespace, image, tag string, arch []string
 		Image:     image,
 		Tag:       tag,
 		Arch:      arch,
-		Interval:  interval,
+		Interval:  schedule.Interval,
+		Cron:      schedule.Cron,
+		Status:    TaskStatusPending,
 	}, nil
 }
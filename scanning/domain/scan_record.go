@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// ArchRecord 单个架构的扫描结果
+type ArchRecord struct {
+	Arch            string                    `json:"arch"`
+	Digest          string                    `json:"digest,omitempty"`
+	Error           string                    `json:"error,omitempty"`
+	Vulnerabilities []Vulnerability           `json:"vulnerabilities"`
+	Suppressed      []SuppressedVulnerability `json:"suppressed,omitempty"`
+}
+
+// ScanRecord 每次扫描的结果，与报告内容保持一致
+type ScanRecord struct {
+	Id        int64
+	TaskId    int64
+	Community string
+	Image     string
+	Owner     Owner
+	Policy    *PolicyResult
+	Arches    []ArchRecord
+	CreatedAt time.Time
+}
+
+func NewScanRecord(task *Task, ars map[string]ArchResult, owner Owner, pr *PolicyResult) ScanRecord {
+	return ScanRecord{
+		TaskId:    task.Id,
+		Community: task.Community,
+		Image:     task.ImagePath(),
+		Owner:     owner,
+		Policy:    pr,
+		Arches:    newArchRecords(ars),
+	}
+}
+
+func newArchRecords(ars map[string]ArchResult) []ArchRecord {
+	arches := make([]ArchRecord, 0, len(ars))
+	for arch, ar := range ars {
+		record := ArchRecord{
+			Arch:       arch,
+			Suppressed: ar.Suppressed,
+		}
+
+		if ar.Err != nil {
+			record.Error = ar.Err.Error()
+		} else {
+			record.Digest = ar.ScanResult.Metadata.Digest()
+			record.Vulnerabilities = ar.ScanResult.validVulnerabilities()
+		}
+
+		arches = append(arches, record)
+	}
+
+	sort.Slice(arches, func(i, j int) bool {
+		return arches[i].Arch < arches[j].Arch
+	})
+
+	return arches
+}
+
+func (r *ScanRecord) HasError() bool {
+	for _, arch := range r.Arches {
+		if arch.Error != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FilterArch 只保留指定架构的结果，arch为空时返回全部架构
+func (r ScanRecord) FilterArch(arch string) ScanRecord {
+	if arch == "" {
+		return r
+	}
+
+	var arches []ArchRecord
+	for _, v := range r.Arches {
+		if v.Arch == arch {
+			arches = append(arches, v)
+		}
+	}
+	r.Arches = arches
+
+	return r
+}
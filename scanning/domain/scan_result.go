@@ -18,13 +18,29 @@ type ScanResult struct {
 }
 
 type Metadata struct {
+	ImageID     string      `json:"ImageID"`
 	RepoTags    []string    `json:"RepoTags"`
+	RepoDigests []string    `json:"RepoDigests"`
 	ImageConfig ImageConfig `json:"ImageConfig"`
 }
 
+// Digest 优先使用镜像仓库中的manifest digest，从本地镜像扫描时没有该信息，使用镜像配置的digest
+func (m Metadata) Digest() string {
+	for _, v := range m.RepoDigests {
+		if _, digest, ok := strings.Cut(v, "@"); ok {
+			return digest
+		}
+	}
+
+	return m.ImageID
+}
+
 type ImageConfig struct {
-	OS   string `json:"os"`
-	Arch string `json:"architecture"`
+	OS     string `json:"os"`
+	Arch   string `json:"architecture"`
+	Config struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"config"`
 }
 
 func (i ImageConfig) GenArch() string {
@@ -55,12 +71,25 @@ func (r Result) formatVulnerabilityID(id string) string {
 }
 
 type Vulnerability struct {
-	VulnerabilityID  string `json:"VulnerabilityID"`
-	PkgName          string `json:"PkgName"`
-	InstalledVersion string `json:"InstalledVersion"`
-	FixedVersion     string `json:"FixedVersion"`
-	Status           string `json:"Status"`
-	Severity         string `json:"Severity"`
+	VulnerabilityID  string     `json:"VulnerabilityID"`
+	PkgName          string     `json:"PkgName"`
+	InstalledVersion string     `json:"InstalledVersion"`
+	FixedVersion     string     `json:"FixedVersion"`
+	Status           string     `json:"Status"`
+	Severity         string     `json:"Severity"`
+	PublishedDate    *time.Time `json:"PublishedDate,omitempty"`
+}
+
+// validVulnerabilities 与报告保持一致，只统计操作系统软件包的漏洞
+func (r ScanResult) validVulnerabilities() []Vulnerability {
+	var vulns []Vulnerability
+	for _, result := range r.Results {
+		if result.isValid() {
+			vulns = append(vulns, result.Vulnerabilities...)
+		}
+	}
+
+	return vulns
 }
 
 func (r ScanResult) ToMarkdown() string {
@@ -101,18 +130,59 @@ func (r ScanResult) ToMarkdown() string {
 	return scanResult + "\n"
 }
 
+type SuppressedVulnerability struct {
+	Vulnerability Vulnerability
+	Statement     VexStatement
+}
+
 type ArchResult struct {
 	Err        error
 	ScanResult ScanResult
+	Suppressed []SuppressedVulnerability
 }
 
-func BuildContent(ars map[string]ArchResult) string {
+func (ar ArchResult) suppressedToMarkdown() string {
+	if len(ar.Suppressed) == 0 {
+		return ""
+	}
+
+	tableHead :=
+		`|  序号  |  软件包  | 漏洞ID | 严重级别 | VEX状态 | 理由 | 声明来源 |
+| :----- | :-----  | :-----  | :----- | :----- | :----- | :----- | `
+
+	rowFormat := `| %d | %s | %s | %s | %s | %s | %s |`
+
+	tableBody := make([]string, 0, len(ar.Suppressed))
+	for i, s := range ar.Suppressed {
+		row := fmt.Sprintf(rowFormat,
+			i+1,
+			s.Vulnerability.PkgName,
+			s.Vulnerability.VulnerabilityID,
+			s.Vulnerability.Severity,
+			s.Statement.Status,
+			s.Statement.Justification,
+			s.Statement.Source,
+		)
+
+		tableBody = append(tableBody, row)
+	}
+
+	return "\n#### 已根据VEX声明排除的漏洞\n" + tableHead + "\n" + strings.Join(tableBody, "\n") + "\n"
+}
+
+func BuildContent(ars map[string]ArchResult, owner Owner, pr *PolicyResult) string {
 	content := fmt.Sprintf("# 扫描时间：%s\n", time.Now().Format(time.DateTime))
+	content += owner.ToMarkdown()
+	if pr != nil {
+		content += pr.ToMarkdown()
+	}
+
 	for arch, ar := range ars {
 		content += fmt.Sprintf("\n--- \n ### 扫描架构：%s \n", arch)
 
 		if ar.Err == nil {
 			content += ar.ScanResult.ToMarkdown()
+			content += ar.suppressedToMarkdown()
 		} else {
 			content += ar.Err.Error() + "\n"
 		}
@@ -0,0 +1,104 @@
+package domain
+
+import "time"
+
+const (
+	ScanOutcomeSucceeded   = "succeeded"
+	ScanOutcomeFailed      = "failed"
+	ScanOutcomeInterrupted = "interrupted"
+)
+
+var ScanOutcomes = []string{ScanOutcomeSucceeded, ScanOutcomeFailed, ScanOutcomeInterrupted}
+
+// ScanRun 任务的一次执行过程，只追加不修改，用于分析吞吐量和耗时的变化
+// 镜像已经在本地缓存时不拉取，BytesPulled为0；漏洞库版本以其更新时间标识
+type ScanRun struct {
+	Id               int64
+	TaskId           int64
+	Community        string
+	Image            string
+	Worker           string
+	StartedAt        time.Time
+	FinishedAt       time.Time
+	PullDuration     time.Duration
+	ScanDuration     time.Duration
+	UploadDuration   time.Duration
+	BytesPulled      int64
+	TrivyDBUpdatedAt time.Time
+	Digests          map[string]string
+	Outcome          string
+	Error            string
+
+	stage        string
+	stageStarted time.Time
+}
+
+func NewScanRun(task *Task, worker string) ScanRun {
+	return ScanRun{
+		TaskId:    task.Id,
+		Community: task.Community,
+		Image:     task.ImagePath(),
+		Worker:    worker,
+		StartedAt: time.Now(),
+	}
+}
+
+// EnterStage 结束上一个阶段的计时，开始新的阶段
+func (r *ScanRun) EnterStage(stage string) {
+	now := time.Now()
+	r.endStage(now)
+
+	r.stage = stage
+	r.stageStarted = now
+}
+
+func (r *ScanRun) endStage(now time.Time) {
+	d := now.Sub(r.stageStarted)
+
+	switch r.stage {
+	case TaskStatusPulling:
+		r.PullDuration += d
+	case TaskStatusScanning:
+		r.ScanDuration += d
+	case TaskStatusUploading:
+		r.UploadDuration += d
+	}
+
+	r.stage = ""
+}
+
+func (r *ScanRun) AddBytesPulled(n int64) {
+	r.BytesPulled += n
+}
+
+// Finish record为空时说明没有得到扫描结果，没有镜像摘要
+func (r *ScanRun) Finish(record *ScanRecord, err error, interrupted bool) {
+	r.FinishedAt = time.Now()
+	r.endStage(r.FinishedAt)
+
+	if record != nil {
+		r.Digests = make(map[string]string, len(record.Arches))
+		for _, arch := range record.Arches {
+			if arch.Digest != "" {
+				r.Digests[arch.Arch] = arch.Digest
+			}
+		}
+	}
+
+	switch {
+	case interrupted:
+		r.Outcome = ScanOutcomeInterrupted
+	case err != nil:
+		r.Outcome = ScanOutcomeFailed
+	default:
+		r.Outcome = ScanOutcomeSucceeded
+	}
+
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+func (r *ScanRun) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
@@ -0,0 +1,218 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	localutils "github.com/opensourceways/image-scanning/utils"
+)
+
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Schedule 扫描周期，可以是时长（1d、1d12h），也可以是标准的5段cron表达式，例如 0 2 * * *，
+// cron表达式可以用 CRON_TZ= 前缀指定时区
+type Schedule struct {
+	Interval int
+	Cron     string
+}
+
+// ParseSchedule cron表达式没有指定时区时使用timezone，与扫描窗口保持一致，timezone为空时使用本地时区
+func ParseSchedule(s, timezone string) (Schedule, error) {
+	if interval, err := localutils.StringToInterval(s); err == nil {
+		if interval <= 0 {
+			return Schedule{}, fmt.Errorf("invalid interval %s: must be greater than zero", s)
+		}
+
+		return Schedule{Interval: interval}, nil
+	}
+
+	if timezone != "" && !strings.HasPrefix(s, "CRON_TZ=") && !strings.HasPrefix(s, "TZ=") {
+		s = "CRON_TZ=" + timezone + " " + s
+	}
+
+	if _, err := cron.ParseStandard(s); err != nil {
+		return Schedule{}, fmt.Errorf("invalid interval %s: neither a duration nor a cron expression", s)
+	}
+
+	return Schedule{Cron: s}, nil
+}
+
+// Next 表达式在生成任务时已经校验过，忽略错误
+func (s Schedule) Next(from time.Time) time.Time {
+	if s.Cron == "" {
+		return from.Add(time.Duration(s.Interval) * time.Second)
+	}
+
+	spec, err := cron.ParseStandard(s.Cron)
+	if err != nil {
+		return from.Add(24 * time.Hour)
+	}
+
+	return spec.Next(from)
+}
+
+func (s Schedule) String() string {
+	if s.Cron != "" {
+		return s.Cron
+	}
+
+	return (time.Duration(s.Interval) * time.Second).String()
+}
+
+// ScanWindow 允许定时扫描的时间段，格式为HH:MM，End不晚于Start时跨越午夜，
+// Days为星期的英文缩写（mon、tue等），为空时每天生效，跨越午夜的时间段以开始的那天为准
+type ScanWindow struct {
+	Days  []string `json:"days"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+// Blackout 禁止定时扫描的时间段，例如版本发布冻结期，时间为RFC3339格式
+type Blackout struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+}
+
+// ScanTimes 社区允许定时扫描的时间，没有配置扫描窗口时全天允许，禁止时间段优先于扫描窗口，
+// 手动触发的扫描不受限制
+type ScanTimes struct {
+	location  *time.Location
+	windows   []scanWindow
+	blackouts []blackout
+}
+
+type scanWindow struct {
+	days       []time.Weekday
+	start, end time.Duration
+}
+
+type blackout struct {
+	from, to time.Time
+}
+
+// ScanTimes 解析global中的扫描窗口和禁止时间段，Timezone为空时使用本地时区
+func (g *Global) ScanTimes() (*ScanTimes, error) {
+	st := &ScanTimes{location: time.Local}
+	if g.Timezone != "" {
+		loc, err := time.LoadLocation(g.Timezone)
+		if err != nil {
+			return nil, err
+		}
+
+		st.location = loc
+	}
+
+	for _, w := range g.ScanWindows {
+		sw, err := w.parse()
+		if err != nil {
+			return nil, err
+		}
+
+		st.windows = append(st.windows, sw)
+	}
+
+	for _, b := range g.Blackouts {
+		from, err := time.Parse(time.RFC3339, b.From)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blackout from %s: %w", b.From, err)
+		}
+
+		to, err := time.Parse(time.RFC3339, b.To)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blackout to %s: %w", b.To, err)
+		}
+
+		if !from.Before(to) {
+			return nil, fmt.Errorf("blackout from %s is not before to %s", b.From, b.To)
+		}
+
+		st.blackouts = append(st.blackouts, blackout{from: from, to: to})
+	}
+
+	return st, nil
+}
+
+func (w ScanWindow) parse() (scanWindow, error) {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return scanWindow{}, err
+	}
+
+	end, err := parseClock(w.End)
+	if err != nil {
+		return scanWindow{}, err
+	}
+
+	sw := scanWindow{start: start, end: end}
+	for _, d := range w.Days {
+		i := slices.Index(weekdays, strings.ToLower(d))
+		if i < 0 {
+			return scanWindow{}, fmt.Errorf("invalid day %s of scan window", d)
+		}
+
+		sw.days = append(sw.days, time.Weekday(i))
+	}
+
+	return sw, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, errors.New("invalid time of scan window, the format is HH:MM: " + s)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Allow now是否允许开始定时扫描
+func (st *ScanTimes) Allow(now time.Time) bool {
+	if st == nil {
+		return true
+	}
+
+	for _, b := range st.blackouts {
+		if !now.Before(b.from) && now.Before(b.to) {
+			return false
+		}
+	}
+
+	if len(st.windows) == 0 {
+		return true
+	}
+
+	now = now.In(st.location)
+	for _, w := range st.windows {
+		if w.contains(now) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w scanWindow) contains(now time.Time) bool {
+	clock := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute
+	day := now.Weekday()
+
+	if w.start < w.end {
+		return clock >= w.start && clock < w.end && w.onDay(day)
+	}
+
+	// 跨越午夜，午夜之后的部分属于前一天开始的窗口
+	if clock >= w.start {
+		return w.onDay(day)
+	}
+
+	return clock < w.end && w.onDay((day+6)%7)
+}
+
+func (w scanWindow) onDay(day time.Weekday) bool {
+	return len(w.days) == 0 || slices.Contains(w.days, day)
+}
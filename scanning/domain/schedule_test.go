@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleRejectsZeroInterval(t *testing.T) {
+	for _, s := range []string{"0m", "0h0m", "0d"} {
+		if _, err := ParseSchedule(s, ""); err == nil {
+			t.Errorf("%s should be rejected", s)
+		}
+	}
+
+	if schedule, err := ParseSchedule("1d12h", ""); err != nil || schedule.Interval != 36*60*60 {
+		t.Errorf("1d12h: got %+v, %v", schedule, err)
+	}
+}
+
+func TestScheduleCronTimezone(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip("no tzdata")
+	}
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	schedule, err := ParseSchedule("0 2 * * *", "Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// from为上海时间08:00，下一次为第二天上海时间02:00，即UTC当天18:00
+	want := time.Date(2024, 1, 2, 2, 0, 0, 0, shanghai)
+	if next := schedule.Next(from); !next.Equal(want) {
+		t.Errorf("next of %s: want %s, got %s", schedule, want, next)
+	}
+
+	// 显式指定的时区优先
+	schedule, err = ParseSchedule("CRON_TZ=UTC 0 2 * * *", "Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)
+	if next := schedule.Next(from); !next.Equal(want) {
+		t.Errorf("next of %s: want %s, got %s", schedule, want, next)
+	}
+
+	if _, err = ParseSchedule("0 2 * * *", "No/Such_Zone"); err == nil {
+		t.Error("unknown timezone should be rejected")
+	}
+}
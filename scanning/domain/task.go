@@ -1,17 +1,49 @@
 package domain
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/opensourceways/image-scanning/scanning/domain/primitive"
+	"github.com/opensourceways/image-scanning/scanning/domain/ratelimiter"
 )
 
 const (
 	ImagesDir = "persistent/images"
+
+	TaskStatusPending     = "pending"
+	TaskStatusPulling     = "pulling"
+	TaskStatusScanning    = "scanning"
+	TaskStatusUploading   = "uploading"
+	TaskStatusSucceeded   = "succeeded"
+	TaskStatusFailed      = "failed"
+	TaskStatusQuarantined = "quarantined"
 )
 
+var TaskStatuses = []string{
+	TaskStatusPending, TaskStatusPulling, TaskStatusScanning, TaskStatusUploading,
+	TaskStatusSucceeded, TaskStatusFailed, TaskStatusQuarantined,
+}
+
+// RetryPolicy 失败后按指数退避重试，退避时间不超过MaxBackoff和距离下次定时扫描的时间，
+// 连续失败QuarantineAfter次后隔离，只有手动触发扫描成功后才恢复
+type RetryPolicy struct {
+	Backoff         time.Duration
+	MaxBackoff      time.Duration
+	QuarantineAfter int
+}
+
+func (p *RetryPolicy) backoff(failures int, limit time.Duration) time.Duration {
+	d := p.Backoff
+	for i := 1; i < failures && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+
+	return min(d, p.MaxBackoff, limit)
+}
+
 type Task struct {
 	Id           int64
 	Community    string
@@ -21,19 +53,33 @@ type Task struct {
 	Tag          string
 	Arch         []string
 	Interval     int
+	Cron         string
+	Priority     int
+	Owner        Owner
+	Paused       bool
 	LastScanTime time.Time
+
+	// 执行状态由任务队列维护，Failures为连续失败次数，失败后在RetryAt重试，
+	// NextScanTime为下次定时扫描的时间，为空时立即扫描
+	Status       string
+	LastError    string
+	Failures     int
+	RetryAt      time.Time
+	NextScanTime time.Time
 }
 
-func GenerateTask(communityName string, cfg *ScanConfig) map[string]Task {
-	initGlobalConfig(communityName, &cfg.Scanner.Global)
+func GenerateTask(
+	ctx context.Context, communityName string, cfg *ScanConfig, limiter ratelimiter.RateLimiter,
+) map[string]Task {
+	global := &cfg.Scanner.Global
 
 	taskSets := make(map[string]Task)
 	for _, repo := range cfg.Repos {
-		repo.genTask(communityName, taskSets)
+		repo.genTask(ctx, communityName, global, limiter, taskSets)
 	}
 
 	for _, image := range cfg.Images {
-		image.genTask(communityName, taskSets)
+		image.genTask(communityName, global, taskSets)
 	}
 
 	return taskSets
@@ -43,19 +89,42 @@ func (t *Task) UniqueKey() string {
 	return fmt.Sprintf("%s-%s-%s-%s-%s", t.Community, t.Registry, t.Namespace, t.Image, t.Tag)
 }
 
-func (t *Task) UpdateIntervalAndArch(interval int, arch []string) {
-	t.Interval = interval
+func (t *Task) Schedule() Schedule {
+	return Schedule{Interval: t.Interval, Cron: t.Cron}
+}
+
+// UpdateScheduleAndArch 扫描周期变化时按新的周期重新计算下次扫描时间，返回扫描周期是否变化
+func (t *Task) UpdateScheduleAndArch(schedule Schedule, arch []string) bool {
 	t.Arch = arch
+
+	if t.Schedule() == schedule {
+		return false
+	}
+
+	t.Interval = schedule.Interval
+	t.Cron = schedule.Cron
+
+	if !t.LastScanTime.IsZero() && t.Failures == 0 {
+		t.NextScanTime = schedule.Next(t.LastScanTime)
+	}
+
+	return true
+}
+
+func (t *Task) UpdatePriority(priority int) {
+	t.Priority = priority
+}
+
+func (t *Task) UpdateOwner(owner Owner) {
+	t.Owner = owner
 }
 
 func (t *Task) IsNeedToScan() bool {
-	if t.LastScanTime.IsZero() {
-		return true
+	if t.Paused || t.IsQuarantined() {
+		return false
 	}
 
-	nextScanTime := t.LastScanTime.Add(time.Second * time.Duration(t.Interval))
-
-	return time.Now().Unix() >= nextScanTime.Unix()
+	return !time.Now().Before(t.NextScanTime)
 }
 
 func (t *Task) ImagePath() string {
@@ -70,6 +139,44 @@ func (t *Task) UpdateLastScanTime() {
 	t.LastScanTime = time.Now()
 }
 
+func (t *Task) IsQuarantined() bool {
+	return t.Status == TaskStatusQuarantined
+}
+
+func (t *Task) SetStatus(status string) {
+	t.Status = status
+}
+
+// Succeed 下次扫描时间从本次开始扫描的时间计算，防止扫描时间不停的向后偏移
+func (t *Task) Succeed() {
+	t.Status = TaskStatusSucceeded
+	t.LastError = ""
+	t.Failures = 0
+	t.RetryAt = time.Time{}
+	t.NextScanTime = t.Schedule().Next(t.LastScanTime)
+}
+
+// Fail 记录失败原因并安排重试，返回是否因本次失败被隔离
+func (t *Task) Fail(err error, policy *RetryPolicy) bool {
+	now := time.Now()
+
+	t.LastError = err.Error()
+	t.Failures++
+	t.RetryAt = now.Add(policy.backoff(t.Failures, t.Schedule().Next(now).Sub(now)))
+	t.NextScanTime = t.RetryAt
+
+	if policy.QuarantineAfter > 0 && t.Failures >= policy.QuarantineAfter {
+		quarantined := !t.IsQuarantined()
+		t.Status = TaskStatusQuarantined
+
+		return quarantined
+	}
+
+	t.Status = TaskStatusFailed
+
+	return false
+}
+
 func (t *Task) MarkdownPath() string {
 	return fmt.Sprintf("%s/%s/%s/%s.md", t.Registry, t.Namespace, t.Image, t.Tag)
 }
@@ -0,0 +1,229 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var ErrInvalidVersionRange = errors.New("invalid version range")
+
+var versionOperators = []string{">=", "<=", "!=", ">", "<", "="}
+
+type versionConstraint struct {
+	op      string
+	version string
+}
+
+// VersionRange 以逗号分隔的约束，所有约束都满足时匹配，例如 ">=1.1.1,<3.0.8"
+type VersionRange []versionConstraint
+
+func ParseVersionRange(s string) (VersionRange, error) {
+	var r VersionRange
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		c := versionConstraint{op: "="}
+		for _, op := range versionOperators {
+			if strings.HasPrefix(item, op) {
+				c.op = op
+				item = strings.TrimSpace(strings.TrimPrefix(item, op))
+				break
+			}
+		}
+
+		if item == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidVersionRange, s)
+		}
+
+		c.version = item
+		r = append(r, c)
+	}
+
+	if len(r) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidVersionRange, s)
+	}
+
+	return r, nil
+}
+
+func (r VersionRange) Contains(version string) bool {
+	for _, c := range r {
+		n := CompareVersion(version, c.version)
+
+		var ok bool
+		switch c.op {
+		case ">=":
+			ok = n >= 0
+		case "<=":
+			ok = n <= 0
+		case "!=":
+			ok = n != 0
+		case ">":
+			ok = n > 0
+		case "<":
+			ok = n < 0
+		default:
+			ok = n == 0
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CompareVersion 按照rpm的版本比较规则比较，支持epoch，也适用于大多数deb版本
+func CompareVersion(a, b string) int {
+	epochA, restA := splitEpoch(a)
+	epochB, restB := splitEpoch(b)
+	if epochA != epochB {
+		if epochA > epochB {
+			return 1
+		}
+
+		return -1
+	}
+
+	return rpmvercmp(restA, restB)
+}
+
+func splitEpoch(v string) (int, string) {
+	epoch, rest, ok := strings.Cut(v, ":")
+	if !ok {
+		return 0, v
+	}
+
+	n, err := strconv.Atoi(epoch)
+	if err != nil {
+		return 0, v
+	}
+
+	return n, rest
+}
+
+func isVersionChar(c rune) bool {
+	return c < unicode.MaxASCII && (unicode.IsDigit(c) || unicode.IsLetter(c) || c == '~' || c == '^')
+}
+
+func rpmvercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	x, y := []rune(a), []rune(b)
+	for len(x) > 0 || len(y) > 0 {
+		for len(x) > 0 && !isVersionChar(x[0]) {
+			x = x[1:]
+		}
+
+		for len(y) > 0 && !isVersionChar(y[0]) {
+			y = y[1:]
+		}
+
+		// ~ 表示预发布版本，比任何内容都旧
+		if (len(x) > 0 && x[0] == '~') || (len(y) > 0 && y[0] == '~') {
+			if len(x) == 0 || x[0] != '~' {
+				return 1
+			}
+
+			if len(y) == 0 || y[0] != '~' {
+				return -1
+			}
+
+			x, y = x[1:], y[1:]
+
+			continue
+		}
+
+		// ^ 表示快照版本，比基础版本新，比下一个版本旧
+		if (len(x) > 0 && x[0] == '^') || (len(y) > 0 && y[0] == '^') {
+			if len(x) == 0 {
+				return -1
+			}
+
+			if len(y) == 0 {
+				return 1
+			}
+
+			if x[0] != '^' {
+				return 1
+			}
+
+			if y[0] != '^' {
+				return -1
+			}
+
+			x, y = x[1:], y[1:]
+
+			continue
+		}
+
+		if len(x) == 0 || len(y) == 0 {
+			break
+		}
+
+		numeric := unicode.IsDigit(x[0])
+		segX, restX := cutSegment(x, numeric)
+		segY, restY := cutSegment(y, numeric)
+		x, y = restX, restY
+
+		// 数字段比字母段新
+		if len(segY) == 0 {
+			if numeric {
+				return 1
+			}
+
+			return -1
+		}
+
+		if n := compareSegment(string(segX), string(segY), numeric); n != 0 {
+			return n
+		}
+	}
+
+	switch {
+	case len(x) == 0 && len(y) == 0:
+		return 0
+	case len(x) > 0:
+		return 1
+	default:
+		return -1
+	}
+}
+
+func cutSegment(v []rune, numeric bool) ([]rune, []rune) {
+	i := 0
+	for i < len(v) && v[i] < unicode.MaxASCII {
+		if numeric && !unicode.IsDigit(v[i]) || !numeric && !unicode.IsLetter(v[i]) {
+			break
+		}
+		i++
+	}
+
+	return v[:i], v[i:]
+}
+
+func compareSegment(a, b string, numeric bool) int {
+	if numeric {
+		a = strings.TrimLeft(a, "0")
+		b = strings.TrimLeft(b, "0")
+
+		if len(a) != len(b) {
+			if len(a) > len(b) {
+				return 1
+			}
+
+			return -1
+		}
+	}
+
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,187 @@
+package domain
+
+import "testing"
+
+// rpmvercmpCases 来自rpm源码中的 tests/rpmvercmp.at
+var rpmvercmpCases = []struct {
+	a, b string
+	want int
+}{
+	{"1.0", "1.0", 0},
+	{"1.0", "2.0", -1},
+	{"2.0", "1.0", 1},
+
+	{"2.0.1", "2.0.1", 0},
+	{"2.0", "2.0.1", -1},
+	{"2.0.1", "2.0", 1},
+
+	{"2.0.1a", "2.0.1a", 0},
+	{"2.0.1a", "2.0.1", 1},
+	{"2.0.1", "2.0.1a", -1},
+
+	{"5.5p1", "5.5p1", 0},
+	{"5.5p1", "5.5p2", -1},
+	{"5.5p2", "5.5p1", 1},
+
+	{"5.5p10", "5.5p10", 0},
+	{"5.5p1", "5.5p10", -1},
+	{"5.5p10", "5.5p1", 1},
+
+	{"10xyz", "10.1xyz", -1},
+	{"10.1xyz", "10xyz", 1},
+
+	{"xyz10", "xyz10", 0},
+	{"xyz10", "xyz10.1", -1},
+	{"xyz10.1", "xyz10", 1},
+
+	{"xyz.4", "xyz.4", 0},
+	{"xyz.4", "8", -1},
+	{"8", "xyz.4", 1},
+	{"xyz.4", "2", -1},
+	{"2", "xyz.4", 1},
+
+	{"5.5p2", "5.6p1", -1},
+	{"5.6p1", "5.5p2", 1},
+
+	{"5.6p1", "6.5p1", -1},
+	{"6.5p1", "5.6p1", 1},
+
+	{"6.0.rc1", "6.0", 1},
+	{"6.0", "6.0.rc1", -1},
+
+	{"10b2", "10a1", 1},
+	{"10a2", "10b2", -1},
+
+	{"1.0aa", "1.0aa", 0},
+	{"1.0a", "1.0aa", -1},
+	{"1.0aa", "1.0a", 1},
+
+	{"10.0001", "10.0001", 0},
+	{"10.0001", "10.1", 0},
+	{"10.1", "10.0001", 0},
+	{"10.0001", "10.0039", -1},
+	{"10.0039", "10.0001", 1},
+
+	{"4.999.9", "5.0", -1},
+	{"5.0", "4.999.9", 1},
+
+	{"20101121", "20101121", 0},
+	{"20101121", "20101122", -1},
+	{"20101122", "20101121", 1},
+
+	{"2_0", "2_0", 0},
+	{"2.0", "2_0", 0},
+	{"2_0", "2.0", 0},
+
+	// 分隔符都视为相同
+	{"a", "a", 0},
+	{"a+", "a+", 0},
+	{"a+", "a_", 0},
+	{"a_", "a+", 0},
+	{"+a", "+a", 0},
+	{"+a", "_a", 0},
+	{"_a", "+a", 0},
+	{"+_", "+_", 0},
+	{"_+", "+_", 0},
+	{"_+", "_+", 0},
+	{"+", "_", 0},
+	{"_", "+", 0},
+
+	// ~ 预发布版本
+	{"1.0~rc1", "1.0~rc1", 0},
+	{"1.0~rc1", "1.0", -1},
+	{"1.0", "1.0~rc1", 1},
+	{"1.0~rc1", "1.0~rc2", -1},
+	{"1.0~rc2", "1.0~rc1", 1},
+	{"1.0~rc1~git123", "1.0~rc1~git123", 0},
+	{"1.0~rc1~git123", "1.0~rc1", -1},
+	{"1.0~rc1", "1.0~rc1~git123", 1},
+
+	// ^ 快照版本
+	{"1.0^", "1.0^", 0},
+	{"1.0^", "1.0", 1},
+	{"1.0", "1.0^", -1},
+	{"1.0^git1", "1.0^git1", 0},
+	{"1.0^git1", "1.0", 1},
+	{"1.0", "1.0^git1", -1},
+	{"1.0^git1", "1.0^git2", -1},
+	{"1.0^git2", "1.0^git1", 1},
+	{"1.0^git1", "1.01", -1},
+	{"1.01", "1.0^git1", 1},
+	{"1.0^20160101", "1.0^20160101", 0},
+	{"1.0^20160101", "1.0.1", -1},
+	{"1.0.1", "1.0^20160101", 1},
+	{"1.0^20160101^git1", "1.0^20160101^git1", 0},
+	{"1.0^20160102", "1.0^20160101^git1", 1},
+	{"1.0^20160101^git1", "1.0^20160102", -1},
+
+	// ~ 和 ^ 混合使用
+	{"1.0~rc1^git1", "1.0~rc1^git1", 0},
+	{"1.0~rc1^git1", "1.0~rc1", 1},
+	{"1.0~rc1", "1.0~rc1^git1", -1},
+	{"1.0^git1~pre", "1.0^git1~pre", 0},
+	{"1.0^git1", "1.0^git1~pre", 1},
+	{"1.0^git1~pre", "1.0^git1", -1},
+
+	// 非ASCII字符视为分隔符
+	{"1.1.α", "1.1.α", 0},
+	{"1.1.α", "1.1.β", 0},
+	{"1.1.αα", "1.1.α", 0},
+	{"1.1.α", "1.1.ββ", 0},
+}
+
+func TestRpmvercmp(t *testing.T) {
+	for _, c := range rpmvercmpCases {
+		if got := rpmvercmp(c.a, c.b); got != c.want {
+			t.Errorf("rpmvercmp(%q, %q): want %d, got %d", c.a, c.b, c.want, got)
+		}
+	}
+}
+
+func TestCompareVersionEpoch(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"1:1.0", "2.0", 1},
+		{"2.0", "1:1.0", -1},
+		{"0:1.0", "1.0", 0},
+		{"1:1.0", "1:1.1", -1},
+		{"2:0.1", "1:9.9", 1},
+		{"1:1.0~rc1", "1:1.0", -1},
+		{"1:1.0^git1", "1:1.0", 1},
+		{"1.2.3-4.oe2403", "1.2.3-10.oe2403", -1},
+	}
+
+	for _, c := range cases {
+		if got := CompareVersion(c.a, c.b); got != c.want {
+			t.Errorf("CompareVersion(%q, %q): want %d, got %d", c.a, c.b, c.want, got)
+		}
+	}
+}
+
+func TestVersionRangeContains(t *testing.T) {
+	r, err := ParseVersionRange(">=1.1.1, <3.0.8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for v, want := range map[string]bool{
+		"1.1.1":     true,
+		"1.1.1~rc1": false,
+		"3.0.7^git": true,
+		"3.0.8~rc1": true,
+		"3.0.8":     false,
+		"1:1.0":     false,
+	} {
+		if got := r.Contains(v); got != want {
+			t.Errorf("%s in %v: want %v, got %v", v, r, want, got)
+		}
+	}
+
+	for _, s := range []string{"", " , ", ">="} {
+		if _, err = ParseVersionRange(s); err == nil {
+			t.Errorf("%q should be rejected", s)
+		}
+	}
+}
@@ -0,0 +1,449 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	VexFormatOpenVex = "openvex"
+	VexFormatCSAF    = "csaf"
+
+	vexStatusNotAffected = "not_affected"
+	vexStatusFixed       = "fixed"
+
+	purlPrefix    = "pkg:"
+	purlOciPrefix = "pkg:oci/"
+)
+
+// VexSource VEX文档的位置，Path为扫描配置仓库中的路径，File为本地文件路径，二者选其一
+type VexSource struct {
+	Path   string `json:"path"`
+	File   string `json:"file"`
+	Format string `json:"format"`
+}
+
+func (s VexSource) String() string {
+	if s.Path != "" {
+		return s.Path
+	}
+
+	return s.File
+}
+
+type VexProduct struct {
+	ID            string
+	Subcomponents []string
+}
+
+type VexStatement struct {
+	Source          string
+	VulnerabilityID string
+	Products        []VexProduct
+	Status          string
+	Justification   string
+}
+
+// matches 判断声明是否适用于镜像image中的漏洞vuln，not_affected的声明中没有产品时对所有镜像生效，
+// fixed的声明没有产品时无法确定修复的版本，不生效
+func (s *VexStatement) matches(image string, vuln *Vulnerability) bool {
+	if s.VulnerabilityID != vuln.VulnerabilityID {
+		return false
+	}
+
+	if len(s.Products) == 0 {
+		return s.Status != vexStatusFixed
+	}
+
+	for _, p := range s.Products {
+		if len(p.Subcomponents) == 0 {
+			if matchImage(p.ID, image) || matchPackage(p.ID, s.Status, vuln) {
+				return true
+			}
+
+			continue
+		}
+
+		if !matchImage(p.ID, image) {
+			continue
+		}
+
+		for _, sub := range p.Subcomponents {
+			if matchPackage(sub, s.Status, vuln) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// matchImage 支持 pkg:oci/ 形式的purl以及 registry/namespace/image[:tag] 形式的镜像地址
+func matchImage(id, image string) bool {
+	if strings.HasPrefix(id, purlOciPrefix) {
+		name, _ := splitPurl(id)
+		return strings.HasSuffix(imageWithoutTag(image), "/"+name)
+	}
+
+	if strings.Contains(id, ":") {
+		return id == image
+	}
+
+	return id == imageWithoutTag(image)
+}
+
+// matchPackage 支持 pkg:rpm/openeuler/openssl@1.1.1m-20 形式的purl，以及包名或者“包名-版本”开头的产品名，
+// 版本需要与安装的版本完全一致；fixed表示某个版本已经修复，没有版本时不适用于任何安装的版本
+func matchPackage(id, status string, vuln *Vulnerability) bool {
+	version := trimEpoch(vuln.InstalledVersion)
+
+	if strings.HasPrefix(id, purlPrefix) {
+		name, v := splitPurl(id)
+		if name != vuln.PkgName {
+			return false
+		}
+
+		if v == "" {
+			return status != vexStatusFixed
+		}
+
+		return trimEpoch(v) == version
+	}
+
+	if id == vuln.PkgName {
+		return status != vexStatusFixed
+	}
+
+	rest, ok := strings.CutPrefix(id, vuln.PkgName+"-"+version)
+
+	return ok && versionEnds(rest)
+}
+
+// versionEnds 版本后面只能是结尾，或者分隔符加上非数字，例如 .oe2203.x86_64，
+// 避免 1.1.1m-2 匹配到 1.1.1m-20 或者 1.1.1m-2.1
+func versionEnds(rest string) bool {
+	if rest == "" {
+		return true
+	}
+
+	if len(rest) < 2 || !strings.ContainsRune(".-_:", rune(rest[0])) {
+		return false
+	}
+
+	return rest[1] < '0' || rest[1] > '9'
+}
+
+// splitPurl 返回purl中的名称和版本
+func splitPurl(purl string) (string, string) {
+	purl, _, _ = strings.Cut(purl, "?")
+	purl, _, _ = strings.Cut(purl, "#")
+
+	name, version, _ := strings.Cut(purl[strings.LastIndex(purl, "/")+1:], "@")
+
+	return name, version
+}
+
+func imageWithoutTag(image string) string {
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i]
+	}
+
+	return image
+}
+
+func trimEpoch(version string) string {
+	if _, v, ok := strings.Cut(version, ":"); ok {
+		return v
+	}
+
+	return version
+}
+
+type VexStatements []VexStatement
+
+// Apply 将not_affected和fixed的声明应用到扫描结果，被排除的漏洞记录到Suppressed中
+func (vs VexStatements) Apply(image string, ar *ArchResult) {
+	if len(vs) == 0 || ar.Err != nil {
+		return
+	}
+
+	for i := range ar.ScanResult.Results {
+		result := &ar.ScanResult.Results[i]
+
+		var remain []Vulnerability
+		for _, vuln := range result.Vulnerabilities {
+			s := vs.find(image, &vuln)
+			if s == nil {
+				remain = append(remain, vuln)
+				continue
+			}
+
+			ar.Suppressed = append(ar.Suppressed, SuppressedVulnerability{
+				Vulnerability: vuln,
+				Statement:     *s,
+			})
+		}
+
+		result.Vulnerabilities = remain
+	}
+}
+
+func (vs VexStatements) find(image string, vuln *Vulnerability) *VexStatement {
+	for i := range vs {
+		if vs[i].matches(image, vuln) {
+			return &vs[i]
+		}
+	}
+
+	return nil
+}
+
+// ParseVex 解析VEX文档，只保留not_affected和fixed的声明
+func ParseVex(source VexSource, data []byte) (VexStatements, error) {
+	switch source.Format {
+	case VexFormatOpenVex:
+		return parseOpenVex(source.String(), data)
+	case VexFormatCSAF:
+		return parseCSAF(source.String(), data)
+	default:
+		return nil, fmt.Errorf("unsupported vex format: %s", source.Format)
+	}
+}
+
+// openVexDocument reference: https://github.com/openvex/spec/blob/main/OPENVEX-SPEC.md
+// 同时兼容v0.0.1中vulnerability和products为字符串的写法
+type openVexDocument struct {
+	Statements []struct {
+		Vulnerability   json.RawMessage   `json:"vulnerability"`
+		Products        []json.RawMessage `json:"products"`
+		Status          string            `json:"status"`
+		Justification   string            `json:"justification"`
+		ImpactStatement string            `json:"impact_statement"`
+	} `json:"statements"`
+}
+
+type openVexComponent struct {
+	ID            string            `json:"@id"`
+	Identifiers   map[string]string `json:"identifiers"`
+	Subcomponents []struct {
+		ID string `json:"@id"`
+	} `json:"subcomponents"`
+}
+
+func parseOpenVex(source string, data []byte) (VexStatements, error) {
+	var doc openVexDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var statements VexStatements
+	for _, s := range doc.Statements {
+		if s.Status != vexStatusNotAffected && s.Status != vexStatusFixed {
+			continue
+		}
+
+		vulnID, err := parseOpenVexVulnerability(s.Vulnerability)
+		if err != nil {
+			return nil, err
+		}
+
+		var products []VexProduct
+		for _, raw := range s.Products {
+			p, err := parseOpenVexProduct(raw)
+			if err != nil {
+				return nil, err
+			}
+
+			products = append(products, p)
+		}
+
+		justification := s.Justification
+		if justification == "" {
+			justification = s.ImpactStatement
+		}
+
+		statements = append(statements, VexStatement{
+			Source:          source,
+			VulnerabilityID: vulnID,
+			Products:        products,
+			Status:          s.Status,
+			Justification:   justification,
+		})
+	}
+
+	return statements, nil
+}
+
+func parseOpenVexVulnerability(raw json.RawMessage) (string, error) {
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		return name, nil
+	}
+
+	var v struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return "", err
+	}
+
+	if v.Name == "" {
+		return "", errors.New("missing vulnerability name")
+	}
+
+	return v.Name, nil
+}
+
+func parseOpenVexProduct(raw json.RawMessage) (VexProduct, error) {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return VexProduct{ID: id}, nil
+	}
+
+	var c openVexComponent
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return VexProduct{}, err
+	}
+
+	p := VexProduct{ID: c.ID}
+	if p.ID == "" {
+		p.ID = c.Identifiers["purl"]
+	}
+
+	for _, sub := range c.Subcomponents {
+		p.Subcomponents = append(p.Subcomponents, sub.ID)
+	}
+
+	return p, nil
+}
+
+// csafDocument reference: https://docs.oasis-open.org/csaf/csaf/v2.0/csaf-v2.0.html
+type csafDocument struct {
+	Document struct {
+		Tracking struct {
+			ID string `json:"id"`
+		} `json:"tracking"`
+	} `json:"document"`
+	ProductTree struct {
+		Branches         []csafBranch      `json:"branches"`
+		FullProductNames []csafProductName `json:"full_product_names"`
+		Relationships    []struct {
+			FullProductName csafProductName `json:"full_product_name"`
+		} `json:"relationships"`
+	} `json:"product_tree"`
+	Vulnerabilities []struct {
+		CVE           string `json:"cve"`
+		ProductStatus struct {
+			Fixed            []string `json:"fixed"`
+			KnownNotAffected []string `json:"known_not_affected"`
+		} `json:"product_status"`
+		Flags []struct {
+			Label      string   `json:"label"`
+			ProductIDs []string `json:"product_ids"`
+		} `json:"flags"`
+	} `json:"vulnerabilities"`
+}
+
+type csafBranch struct {
+	Branches []csafBranch    `json:"branches"`
+	Product  csafProductName `json:"product"`
+}
+
+type csafProductName struct {
+	Name      string `json:"name"`
+	ProductID string `json:"product_id"`
+	Helper    struct {
+		Purl string `json:"purl"`
+	} `json:"product_identification_helper"`
+}
+
+func (p csafProductName) identifier() string {
+	if p.Helper.Purl != "" {
+		return p.Helper.Purl
+	}
+
+	if p.Name != "" {
+		return p.Name
+	}
+
+	return p.ProductID
+}
+
+func parseCSAF(source string, data []byte) (VexStatements, error) {
+	var doc csafDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	products := make(map[string]string)
+	addProduct := func(p csafProductName) {
+		if p.ProductID != "" {
+			products[p.ProductID] = p.identifier()
+		}
+	}
+
+	var walk func([]csafBranch)
+	walk = func(branches []csafBranch) {
+		for _, b := range branches {
+			addProduct(b.Product)
+			walk(b.Branches)
+		}
+	}
+	walk(doc.ProductTree.Branches)
+
+	for _, p := range doc.ProductTree.FullProductNames {
+		addProduct(p)
+	}
+
+	for _, r := range doc.ProductTree.Relationships {
+		addProduct(r.FullProductName)
+	}
+
+	toProducts := func(ids []string) []VexProduct {
+		ps := make([]VexProduct, 0, len(ids))
+		for _, id := range ids {
+			if v, ok := products[id]; ok {
+				ps = append(ps, VexProduct{ID: v})
+			} else {
+				ps = append(ps, VexProduct{ID: id})
+			}
+		}
+
+		return ps
+	}
+
+	source = fmt.Sprintf("%s(%s)", source, doc.Document.Tracking.ID)
+
+	var statements VexStatements
+	for _, v := range doc.Vulnerabilities {
+		justifications := make(map[string]string)
+		for _, f := range v.Flags {
+			for _, id := range f.ProductIDs {
+				justifications[id] = f.Label
+			}
+		}
+
+		for _, id := range v.ProductStatus.KnownNotAffected {
+			statements = append(statements, VexStatement{
+				Source:          source,
+				VulnerabilityID: v.CVE,
+				Products:        toProducts([]string{id}),
+				Status:          vexStatusNotAffected,
+				Justification:   justifications[id],
+			})
+		}
+
+		if len(v.ProductStatus.Fixed) > 0 {
+			statements = append(statements, VexStatement{
+				Source:          source,
+				VulnerabilityID: v.CVE,
+				Products:        toProducts(v.ProductStatus.Fixed),
+				Status:          vexStatusFixed,
+			})
+		}
+	}
+
+	return statements, nil
+}
@@ -0,0 +1,106 @@
+package domain
+
+import "testing"
+
+func TestMatchPackage(t *testing.T) {
+	vuln := &Vulnerability{VulnerabilityID: "CVE-2024-0001", PkgName: "openssl", InstalledVersion: "1:1.1.1m-2"}
+
+	cases := []struct {
+		name   string
+		id     string
+		status string
+		want   bool
+	}{
+		{"name and exact version", "openssl-1.1.1m-2", vexStatusFixed, true},
+		{"name, version and dist", "openssl-1.1.1m-2.oe2203.x86_64", vexStatusFixed, true},
+		{"longer release", "openssl-1.1.1m-20", vexStatusFixed, false},
+		{"longer release with dist", "openssl-1.1.1m-20.oe2203", vexStatusFixed, false},
+		{"sub release", "openssl-1.1.1m-2.1", vexStatusFixed, false},
+		{"other version", "openssl-1.1.1n-2", vexStatusFixed, false},
+		{"other package", "openssl-libs-1.1.1m-2", vexStatusFixed, false},
+		{"bare name fixed", "openssl", vexStatusFixed, false},
+		{"bare name not affected", "openssl", vexStatusNotAffected, true},
+		{"purl exact version", "pkg:rpm/openeuler/openssl@1.1.1m-2?arch=x86_64", vexStatusFixed, true},
+		{"purl with epoch", "pkg:rpm/openeuler/openssl@1:1.1.1m-2", vexStatusFixed, true},
+		{"purl other version", "pkg:rpm/openeuler/openssl@1.1.1m-20", vexStatusFixed, false},
+		{"purl without version fixed", "pkg:rpm/openeuler/openssl", vexStatusFixed, false},
+		{"purl without version not affected", "pkg:rpm/openeuler/openssl", vexStatusNotAffected, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := matchPackage(c.id, c.status, vuln); got != c.want {
+				t.Errorf("matchPackage(%q, %s) = %v, want %v", c.id, c.status, got, c.want)
+			}
+		})
+	}
+}
+
+func TestVexStatementMatches(t *testing.T) {
+	const image = "docker.io/openeuler/openeuler:24.03"
+
+	vuln := &Vulnerability{VulnerabilityID: "CVE-2024-0001", PkgName: "openssl", InstalledVersion: "1.1.1m-2"}
+
+	cases := []struct {
+		name      string
+		statement VexStatement
+		want      bool
+	}{
+		{
+			name:      "not affected without products",
+			statement: VexStatement{VulnerabilityID: "CVE-2024-0001", Status: vexStatusNotAffected},
+			want:      true,
+		},
+		{
+			name:      "fixed without products",
+			statement: VexStatement{VulnerabilityID: "CVE-2024-0001", Status: vexStatusFixed},
+			want:      false,
+		},
+		{
+			name: "other vulnerability",
+			statement: VexStatement{
+				VulnerabilityID: "CVE-2024-0002", Status: vexStatusNotAffected,
+				Products: []VexProduct{{ID: "openssl"}},
+			},
+			want: false,
+		},
+		{
+			name: "image with package subcomponent",
+			statement: VexStatement{
+				VulnerabilityID: "CVE-2024-0001", Status: vexStatusFixed,
+				Products: []VexProduct{{
+					ID:            "pkg:oci/openeuler?repository_url=docker.io/openeuler",
+					Subcomponents: []string{"pkg:rpm/openeuler/openssl@1.1.1m-2"},
+				}},
+			},
+			want: true,
+		},
+		{
+			name: "image with package subcomponent of other release",
+			statement: VexStatement{
+				VulnerabilityID: "CVE-2024-0001", Status: vexStatusFixed,
+				Products: []VexProduct{{
+					ID:            "docker.io/openeuler/openeuler",
+					Subcomponents: []string{"openssl-1.1.1m-20"},
+				}},
+			},
+			want: false,
+		},
+		{
+			name: "other image",
+			statement: VexStatement{
+				VulnerabilityID: "CVE-2024-0001", Status: vexStatusNotAffected,
+				Products: []VexProduct{{ID: "docker.io/openeuler/other", Subcomponents: []string{"openssl"}}},
+			},
+			want: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.statement.matches(image, vuln); got != c.want {
+				t.Errorf("matches() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
@@ -1,64 +1,188 @@
 package scanning
 
 import (
+	"context"
+	"net/http"
+	"time"
+
 	"github.com/robfig/cron/v3"
 	"github.com/sirupsen/logrus"
 
+	"github.com/opensourceways/image-scanning/common/infrastructure/command"
+	"github.com/opensourceways/image-scanning/common/infrastructure/leader"
+	"github.com/opensourceways/image-scanning/common/infrastructure/postgresql"
 	"github.com/opensourceways/image-scanning/config"
 	"github.com/opensourceways/image-scanning/scanning/app"
+	"github.com/opensourceways/image-scanning/scanning/controller"
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/infrastructure/messageimpl"
+	"github.com/opensourceways/image-scanning/scanning/infrastructure/metrics"
+	"github.com/opensourceways/image-scanning/scanning/infrastructure/notifierimpl"
+	"github.com/opensourceways/image-scanning/scanning/infrastructure/ratelimiterimpl"
 	"github.com/opensourceways/image-scanning/scanning/infrastructure/repositoryimpl"
 )
 
+// healthPort 存活和就绪探针使用的端口
+const healthPort = 8080
+
 var instance *scanner
 
 type scanner struct {
-	job          *cron.Cron
-	cfg          *config.Config
-	trivyService app.TrivyService
-	taskService  app.TaskService
+	job           *cron.Cron
+	cfg           *config.Config
+	elector       *leader.Elector
+	trivyService  app.TrivyService
+	taskService   app.TaskService
+	adhocService  app.AdhocService
+	digestService app.DigestService
+	healthService app.HealthService
 }
 
-func Run(cfg *config.Config) {
-	trivyService := app.NewTrivyService(&cfg.TrivyRepo)
-	taskService := app.NewTaskService(cfg.Community, cfg.Concurrency, repositoryimpl.NewTaskImpl())
+// Run 阻塞到ctx结束，然后在gracePeriod内等待正在运行的扫描完成
+func Run(ctx context.Context, cfg *config.Config, opt controller.ServerOptions, gracePeriod time.Duration) {
+	// 探针在初始化期间就要能访问，trivy初始化完成前存活检查通过、就绪检查不通过
+	healthService := app.NewHealthService(&cfg.Health, postgresql.Ping)
+	healthServer := controller.StartHealthServer(healthPort, healthService)
+
+	var webhooks []domain.Webhook
+	for _, c := range cfg.Community {
+		webhooks = append(webhooks, c.Notification.Webhooks...)
+	}
+
+	// 多副本时只有leader同步任务、发送报告，扫描任务通过数据库队列在副本间分配
+	elector := leader.NewElector(&cfg.Leader, postgresql.NewAdvisoryLock(cfg.Leader.LockKey))
+	elector.Start()
+
+	publisher := messageimpl.NewPublisherImpl(&cfg.Message)
+	runner := command.NewRunner(&cfg.Command)
+	imageScanner := app.NewImageScanner(runner, &cfg.Timeout, ratelimiterimpl.NewRateLimiter(&cfg.RateLimit))
+	trivyService := app.NewTrivyService(
+		&cfg.TrivyRepo, notifierimpl.NewNotifier(webhooks), publisher, runner, &cfg.Timeout, elector.IsLeader,
+	)
+	taskRepo := repositoryimpl.NewTaskImpl()
+	recordRepo := repositoryimpl.NewScanRecordImpl()
+	findingRepo := repositoryimpl.NewFindingImpl()
+	runRepo := repositoryimpl.NewScanRunImpl()
+	taskService := app.NewTaskService(cfg.Community, cfg.Concurrency, &cfg.Retry, app.Repositories{
+		Task:      taskRepo,
+		Record:    recordRepo,
+		Issue:     repositoryimpl.NewIssueImpl(),
+		Community: repositoryimpl.NewCommunityImpl(),
+		Finding:   findingRepo,
+		Run:       runRepo,
+	}, publisher, imageScanner, elector.IsLeader)
+	findingService := app.NewFindingService(cfg.Community, findingRepo, recordRepo)
+	metrics.RegisterVulnerabilityCollector(findingRepo.CountBySeverity)
 
 	instance = &scanner{
-		job:          cron.New(),
-		cfg:          cfg,
-		trivyService: trivyService,
-		taskService:  taskService,
+		job:           cron.New(),
+		cfg:           cfg,
+		elector:       elector,
+		trivyService:  trivyService,
+		taskService:   taskService,
+		adhocService:  app.NewAdhocService(&cfg.Adhoc, repositoryimpl.NewAdhocScanImpl(), imageScanner),
+		digestService: app.NewDigestService(cfg.Community, recordRepo),
+		healthService: healthService,
 	}
 
-	if err := instance.trivyService.InitTrivyEnv(); err != nil {
+	if err := instance.trivyService.InitTrivyEnv(ctx); err != nil {
 		logrus.Fatalf("init trivy env failed: %s", err.Error())
 	}
 
+	// 首次启用漏洞查询时使用已有的扫描记录初始化，需要在扫描任务开始前完成，避免覆盖新的结果
+	findingService.Backfill()
+
 	// 程序启动先同步一次任务
 	instance.taskService.GenerateTask()
 
-	instance.addJob()
+	instance.addJob(ctx)
+	instance.healthService.MarkInitialized()
+
+	apiServer := controller.StartServer(opt, &cfg.Api, controller.Services{
+		Task:    taskService,
+		Result:  app.NewResultService(taskRepo, recordRepo),
+		Adhoc:   instance.adhocService,
+		Finding: findingService,
+		Run:     app.NewRunService(taskRepo, runRepo),
+		Health:  instance.healthService,
+	})
 
-	instance.job.Run()
+	instance.job.Start()
+
+	<-ctx.Done()
+	logrus.Infof("shutting down, wait at most %s for the running scans", gracePeriod)
+
+	instance.shutdown(gracePeriod, apiServer, healthServer)
 }
 
-func (s *scanner) addJob() {
-	// 每小时同步一次配置文件，更新扫描任务
-	if _, err := s.job.AddFunc("55 * * * *", s.taskService.GenerateTask); err != nil {
-		logrus.Fatalf("add cron job [GenerateTask]  failed: %s", err.Error())
+// shutdown 先停止定时任务和接口，不再接收新的扫描，再等待正在运行的扫描；
+// 健康检查服务最后关闭，保证退出过程中探针仍然可用
+func (s *scanner) shutdown(gracePeriod time.Duration, apiServer, healthServer *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+
+	cronCtx := s.job.Stop()
+
+	if err := apiServer.Shutdown(ctx); err != nil {
+		logrus.Errorf("shutdown api server failed: %s", err.Error())
 	}
 
-	// 看配置要求调整执行任务的粒度，保证覆盖就可以，一般不会太频繁
-	if _, err := s.job.AddFunc("*/1 * * * *", s.taskService.ExecTask); err != nil {
-		logrus.Fatalf("add cron job [ExecTask]  failed: %s", err.Error())
+	s.taskService.Shutdown(ctx)
+	s.adhocService.Shutdown(ctx)
+
+	select {
+	case <-cronCtx.Done():
+	case <-ctx.Done():
+		logrus.Warn("cron jobs are not finished in the grace period")
 	}
 
-	// 每6小时，trivy的标准周期
-	if _, err := s.job.AddFunc("0 */6 * * *", s.trivyService.UpdateTrivyDB); err != nil {
-		logrus.Fatalf("add cron job [UpdateTrivyDB]  failed: %s", err.Error())
+	s.elector.Stop(ctx)
+
+	if err := healthServer.Shutdown(ctx); err != nil {
+		healthServer.Close()
 	}
+}
+
+// addJob trivy db和镜像缓存在每个副本本地，更新和清理在每个副本上执行，ctx结束时终止漏洞库的下载
+func (s *scanner) addJob(ctx context.Context) {
+	// 每小时同步一次配置文件，更新扫描任务，只有leader写入任务
+	s.addFunc("55 * * * *", app.JobGenerateTask, s.taskService.GenerateTask)
+
+	// 看配置要求调整执行任务的粒度，保证覆盖就可以，一般不会太频繁
+	s.addFunc("*/1 * * * *", app.JobExecTask, s.taskService.ExecTask)
+
+	// 每6小时，trivy的标准周期
+	s.addFuncWithError("0 */6 * * *", "UpdateTrivyDB", func() error {
+		return s.trivyService.UpdateTrivyDB(ctx)
+	})
 
 	// 镜像站的镜像会按照月级更新，定期清理重新拉取
-	if _, err := s.job.AddFunc("0 0 1 * *", s.taskService.ClearImages); err != nil {
-		logrus.Fatalf("add cron job [ClearImages]  failed: %s", err.Error())
+	s.addFunc("0 0 1 * *", "ClearImages", s.taskService.ClearImages)
+
+	// 每天早上9点发送日报，周一同时发送周报
+	s.addFunc("0 9 * * *", "SendDailyDigest", s.leaderOnly(s.digestService.SendDailyDigest))
+	s.addFunc("0 9 * * 1", "SendWeeklyDigest", s.leaderOnly(s.digestService.SendWeeklyDigest))
+}
+
+func (s *scanner) leaderOnly(f func()) func() {
+	return func() {
+		if s.elector.IsLeader() {
+			f()
+		}
+	}
+}
+
+func (s *scanner) addFunc(spec, name string, f func()) {
+	s.addFuncWithError(spec, name, func() error {
+		f()
+
+		return nil
+	})
+}
+
+// addFuncWithError 记录定时任务的运行时间，返回错误时不更新最近成功时间
+func (s *scanner) addFuncWithError(spec, name string, f func() error) {
+	if _, err := s.job.AddFunc(spec, metrics.CronJob(name, s.healthService.Track(name, f))); err != nil {
+		logrus.Fatalf("add cron job [%s]  failed: %s", name, err.Error())
 	}
 }
@@ -0,0 +1,155 @@
+package fakeimpl
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+)
+
+// NewAdhocScanImpl 与数据库实现一致，没有找到时返回gorm.ErrRecordNotFound
+func NewAdhocScanImpl() *adhocScanImpl {
+	return &adhocScanImpl{
+		scans:  make(map[string]domain.AdhocScan),
+		leases: make(map[string]lease),
+	}
+}
+
+type adhocScanImpl struct {
+	mu     sync.Mutex
+	scans  map[string]domain.AdhocScan
+	leases map[string]lease
+}
+
+func (impl *adhocScanImpl) Add(scan *domain.AdhocScan) error {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	impl.scans[scan.Id] = *scan
+
+	return nil
+}
+
+func (impl *adhocScanImpl) Find(id string) (domain.AdhocScan, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	scan, ok := impl.scans[id]
+	if !ok {
+		return domain.AdhocScan{}, gorm.ErrRecordNotFound
+	}
+
+	return scan, nil
+}
+
+func (impl *adhocScanImpl) CountQueued() (int64, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	var total int64
+	for _, scan := range impl.scans {
+		if scan.Status == domain.AdhocStatusQueued {
+			total++
+		}
+	}
+
+	return total, nil
+}
+
+// Claim 与数据库实现一致，每次最多领取一个，先提交的先领取
+func (impl *adhocScanImpl) Claim(owner, node string, d time.Duration) ([]domain.AdhocScan, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	now := time.Now()
+
+	candidates := make([]domain.AdhocScan, 0, len(impl.scans))
+	for _, scan := range impl.scans {
+		claimable := scan.Status == domain.AdhocStatusQueued ||
+			scan.Status == domain.AdhocStatusRunning && impl.leases[scan.Id].expired(now)
+		if claimable && (!scan.IsArchive() || scan.Node == node) {
+			candidates = append(candidates, scan)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].CreatedAt.Before(candidates[j].CreatedAt)
+	})
+
+	scan := candidates[0]
+	scan.Start()
+	impl.scans[scan.Id] = scan
+	impl.leases[scan.Id] = lease{owner: owner, until: now.Add(d)}
+
+	return []domain.AdhocScan{scan}, nil
+}
+
+func (impl *adhocScanImpl) RenewLease(id, owner string, d time.Duration) error {
+	return impl.updateLeased(id, owner, func(scan *domain.AdhocScan, l *lease) {
+		l.until = time.Now().Add(d)
+	})
+}
+
+func (impl *adhocScanImpl) Complete(scan *domain.AdhocScan, owner string) error {
+	return impl.updateLeased(scan.Id, owner, func(s *domain.AdhocScan, l *lease) {
+		*s = *scan
+		*l = lease{}
+	})
+}
+
+func (impl *adhocScanImpl) updateLeased(id, owner string, update func(*domain.AdhocScan, *lease)) error {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	scan, ok := impl.scans[id]
+	l := impl.leases[id]
+	if !ok || l.owner != owner {
+		return repository.ErrLeaseLost
+	}
+
+	update(&scan, &l)
+	impl.scans[id] = scan
+	impl.leases[id] = l
+
+	return nil
+}
+
+func (impl *adhocScanImpl) FailQueued(node, reason string) ([]domain.AdhocScan, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	var failed []domain.AdhocScan
+	for id, scan := range impl.scans {
+		if scan.Node == node && scan.Status == domain.AdhocStatusQueued {
+			scan.Status = domain.AdhocStatusFailed
+			scan.Error = reason
+			scan.FinishedAt = time.Now()
+			impl.scans[id] = scan
+			failed = append(failed, scan)
+		}
+	}
+
+	return failed, nil
+}
+
+func (impl *adhocScanImpl) DeleteBefore(t time.Time) error {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	for id, scan := range impl.scans {
+		if scan.CreatedAt.Before(t) {
+			delete(impl.scans, id)
+			delete(impl.leases, id)
+		}
+	}
+
+	return nil
+}
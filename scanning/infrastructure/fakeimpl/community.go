@@ -0,0 +1,30 @@
+package fakeimpl
+
+import "sync"
+
+func NewCommunityImpl() *communityImpl {
+	return &communityImpl{
+		paused: make(map[string]bool),
+	}
+}
+
+type communityImpl struct {
+	mu     sync.Mutex
+	paused map[string]bool
+}
+
+func (impl *communityImpl) IsPaused(name string) (bool, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	return impl.paused[name], nil
+}
+
+func (impl *communityImpl) SetPaused(name string, paused bool) error {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	impl.paused[name] = paused
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package fakeimpl
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+)
+
+func NewFindingImpl() *findingImpl {
+	return &findingImpl{}
+}
+
+type findingImpl struct {
+	mu       sync.Mutex
+	findings []domain.Finding
+}
+
+func (impl *findingImpl) Replace(taskId int64, keepArches []string, findings []domain.Finding) error {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	impl.findings = slices.DeleteFunc(impl.findings, func(f domain.Finding) bool {
+		return f.TaskId == taskId && !slices.Contains(keepArches, f.Arch)
+	})
+	impl.findings = append(impl.findings, findings...)
+
+	return nil
+}
+
+func (impl *findingImpl) DeleteByTaskIds(ids []int64) error {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	impl.findings = slices.DeleteFunc(impl.findings, func(f domain.Finding) bool {
+		return slices.Contains(ids, f.TaskId)
+	})
+
+	return nil
+}
+
+func (impl *findingImpl) List(opt *repository.FindingListOption) ([]domain.Finding, int64, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	var findings []domain.Finding
+	for _, f := range impl.findings {
+		if (opt.Community == "" || f.Community == opt.Community) &&
+			(opt.VulnerabilityID == "" || f.VulnerabilityID == opt.VulnerabilityID) &&
+			(opt.PkgName == "" || f.PkgName == opt.PkgName) &&
+			(len(opt.Severities) == 0 || slices.Contains(opt.Severities, f.Severity)) &&
+			(opt.Fixable == nil || f.IsFixable() == *opt.Fixable) {
+			findings = append(findings, f)
+		}
+	}
+
+	return page(findings, opt.Page, opt.Size), int64(len(findings)), nil
+}
+
+func (impl *findingImpl) IsEmpty() (bool, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	return len(impl.findings) == 0, nil
+}
+
+// CountBySeverity 同一个任务多个架构中的同一个漏洞只计算一次
+func (impl *findingImpl) CountBySeverity() ([]domain.SeverityCount, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	type key struct {
+		community, severity string
+	}
+
+	type vuln struct {
+		taskId      int64
+		id, pkgName string
+	}
+
+	seen := make(map[key]map[vuln]bool)
+	var keys []key
+	for _, f := range impl.findings {
+		k := key{f.Community, f.Severity}
+		if seen[k] == nil {
+			seen[k] = make(map[vuln]bool)
+			keys = append(keys, k)
+		}
+
+		seen[k][vuln{f.TaskId, f.VulnerabilityID, f.PkgName}] = true
+	}
+
+	counts := make([]domain.SeverityCount, len(keys))
+	for i, k := range keys {
+		counts[i] = domain.SeverityCount{Community: k.community, Severity: k.severity, Count: int64(len(seen[k]))}
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,81 @@
+package fakeimpl
+
+import (
+	"sync"
+
+	"gorm.io/gorm"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+func NewIssueImpl() *issueImpl {
+	return &issueImpl{}
+}
+
+// issueImpl modifyLock模拟数据库的行锁，同一时间只有一个Modify在执行
+type issueImpl struct {
+	modifyLock sync.Mutex
+
+	mu     sync.Mutex
+	issues []domain.Issue
+}
+
+func (impl *issueImpl) Modify(community, key string, modify func(issue *domain.Issue) bool) error {
+	impl.modifyLock.Lock()
+	defer impl.modifyLock.Unlock()
+
+	issue := impl.findOrCreate(community, key)
+	if !modify(&issue) {
+		return nil
+	}
+
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	impl.issues[issue.Id-1] = issue
+
+	return nil
+}
+
+func (impl *issueImpl) findOrCreate(community, key string) domain.Issue {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	for i := range impl.issues {
+		if impl.issues[i].Community == community && impl.issues[i].Key == key {
+			return impl.issues[i]
+		}
+	}
+
+	issue := domain.Issue{Id: int64(len(impl.issues) + 1), Community: community, Key: key}
+	impl.issues = append(impl.issues, issue)
+
+	return issue
+}
+
+func (impl *issueImpl) Find(community, key string) (domain.Issue, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	for i := range impl.issues {
+		if impl.issues[i].Community == community && impl.issues[i].Key == key {
+			return impl.issues[i], nil
+		}
+	}
+
+	return domain.Issue{}, gorm.ErrRecordNotFound
+}
+
+func (impl *issueImpl) FindOpen(community string) ([]domain.Issue, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	var issues []domain.Issue
+	for i := range impl.issues {
+		if impl.issues[i].Community == community && impl.issues[i].IsOpen() {
+			issues = append(issues, impl.issues[i])
+		}
+	}
+
+	return issues, nil
+}
@@ -0,0 +1,143 @@
+package fakeimpl
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+// NewPlatformImpl sha不变时视为扫描配置没有变化
+func NewPlatformImpl(cfg domain.ScanConfig, sha string) *platformImpl {
+	return &platformImpl{
+		scanConfig: cfg,
+		sha:        sha,
+		files:      make(map[string][]byte),
+		uploads:    make(map[string]string),
+		issues:     make(map[string]domain.IssueContent),
+	}
+}
+
+type platformImpl struct {
+	mu         sync.Mutex
+	scanConfig domain.ScanConfig
+	sha        string
+	output     domain.Output
+	files      map[string][]byte
+	uploads    map[string]string
+	issues     map[string]domain.IssueContent
+	issueNum   int
+}
+
+// SetScanConfig 模拟社区修改扫描配置
+func (p *platformImpl) SetScanConfig(cfg domain.ScanConfig, sha string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.scanConfig = cfg
+	p.sha = sha
+}
+
+// SetConfigFile 设置DownloadConfigFile返回的文件内容，例如VEX文档
+func (p *platformImpl) SetConfigFile(path string, content []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.files[path] = content
+}
+
+// Uploaded 返回上传到path的报告
+func (p *platformImpl) Uploaded(path string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	content, ok := p.uploads[path]
+
+	return content, ok
+}
+
+// Issues 返回创建的issue，key为repo/number
+func (p *platformImpl) Issues() map[string]domain.IssueContent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	issues := make(map[string]domain.IssueContent, len(p.issues))
+	for k, v := range p.issues {
+		issues[k] = v
+	}
+
+	return issues
+}
+
+func (p *platformImpl) Upload(content, path string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.uploads[path] = content
+
+	return nil
+}
+
+func (p *platformImpl) SetOutput(output domain.Output) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.output = output
+}
+
+func (p *platformImpl) DownloadScanConfig() (domain.ScanConfig, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.scanConfig, p.sha, nil
+}
+
+func (p *platformImpl) DownloadConfigFile(path string) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	content, ok := p.files[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+
+	return content, nil
+}
+
+func (p *platformImpl) CreateIssue(repo string, content domain.IssueContent) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.issueNum++
+	number := fmt.Sprint(p.issueNum)
+	p.issues[repo+"/"+number] = content
+
+	return number, nil
+}
+
+func (p *platformImpl) UpdateIssue(repo, number string, content domain.IssueContent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.issues[repo+"/"+number]; !ok {
+		return fmt.Errorf("issue %s/%s not found", repo, number)
+	}
+
+	p.issues[repo+"/"+number] = content
+
+	return nil
+}
+
+func (p *platformImpl) CloseIssue(repo, number string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.issues[repo+"/"+number]; !ok {
+		return fmt.Errorf("issue %s/%s not found", repo, number)
+	}
+
+	delete(p.issues, repo+"/"+number)
+
+	return nil
+}
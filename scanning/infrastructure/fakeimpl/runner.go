@@ -0,0 +1,123 @@
+// Package fakeimpl 内存中的仓库、平台和模拟skopeo/trivy的命令实现，
+// 用于在没有数据库、代码平台和镜像仓库的环境中运行完整的扫描流程
+package fakeimpl
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/opensourceways/image-scanning/common/infrastructure/command"
+)
+
+const (
+	skopeo       = "skopeo"
+	trivy        = "trivy"
+	ociPrefix    = "oci:./"
+	defaultTrivy = "default.json"
+)
+
+// NewRunner trivy的输出从fixtureDir中读取，文件名为镜像本地目录名加.json，不存在时使用default.json
+func NewRunner(fixtureDir string) *runner {
+	return &runner{
+		fixtureDir: fixtureDir,
+		failures:   make(map[string]error),
+	}
+}
+
+type runner struct {
+	fixtureDir string
+
+	mu       sync.Mutex
+	calls    []command.Cmd
+	failures map[string]error
+}
+
+// Fail 命令行中包含keyword的命令返回err，例如镜像名或者架构
+func (r *runner) Fail(keyword string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.failures[keyword] = err
+}
+
+// Calls 返回已经执行的命令
+func (r *runner) Calls() []command.Cmd {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]command.Cmd(nil), r.calls...)
+}
+
+func (r *runner) Run(ctx context.Context, c command.Cmd) (string, error) {
+	if err := r.record(c); err != nil {
+		// 与skopeo和trivy一样，失败原因输出在stderr中
+		return "", &command.Error{Cmd: c.String(), ExitCode: 1, Stderr: err.Error(), Err: err}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", &command.Error{Cmd: c.String(), ExitCode: -1, Err: err}
+	}
+
+	switch filepath.Base(c.Name) {
+	case skopeo:
+		return "", r.copy(c.Args)
+	case trivy:
+		return r.scan(c.Args)
+	default:
+		return "", nil
+	}
+}
+
+func (r *runner) record(c command.Cmd) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, c)
+
+	line := c.String()
+	for keyword, err := range r.failures {
+		if strings.Contains(line, keyword) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copy 创建skopeo copy的目标目录，模拟下载完成的oci镜像
+func (r *runner) copy(args []string) error {
+	if len(args) == 0 || !strings.HasPrefix(args[len(args)-1], ociPrefix) {
+		return errors.New("missing oci destination")
+	}
+
+	dir := strings.TrimPrefix(args[len(args)-1], ociPrefix)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, "oci-layout"), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0600)
+}
+
+func (r *runner) scan(args []string) (string, error) {
+	input := ""
+	for i := range args {
+		if args[i] == "--input" && i+1 < len(args) {
+			input = args[i+1]
+		}
+	}
+
+	if input == "" {
+		return "", errors.New("missing input")
+	}
+
+	data, err := os.ReadFile(filepath.Join(r.fixtureDir, filepath.Base(input)+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		data, err = os.ReadFile(filepath.Join(r.fixtureDir, defaultTrivy))
+	}
+
+	return string(data), err
+}
@@ -0,0 +1,76 @@
+package fakeimpl
+
+import (
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+func NewScanRecordImpl() *scanRecordImpl {
+	return &scanRecordImpl{}
+}
+
+// scanRecordImpl 按保存顺序存放，id递增
+type scanRecordImpl struct {
+	mu      sync.Mutex
+	records []domain.ScanRecord
+}
+
+func (impl *scanRecordImpl) Save(record *domain.ScanRecord) error {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	record.Id = int64(len(impl.records) + 1)
+	record.CreatedAt = time.Now()
+	impl.records = append(impl.records, *record)
+
+	return nil
+}
+
+func (impl *scanRecordImpl) FindLatest(taskId int64) (domain.ScanRecord, error) {
+	records, _, _ := impl.FindByTask(taskId, 1, 1)
+	if len(records) == 0 {
+		return domain.ScanRecord{}, gorm.ErrRecordNotFound
+	}
+
+	return records[0], nil
+}
+
+func (impl *scanRecordImpl) FindByTask(taskId int64, p, size int) ([]domain.ScanRecord, int64, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	var records []domain.ScanRecord
+	for i := len(impl.records) - 1; i >= 0; i-- {
+		if impl.records[i].TaskId == taskId {
+			records = append(records, impl.records[i])
+		}
+	}
+
+	return page(records, p, size), int64(len(records)), nil
+}
+
+func (impl *scanRecordImpl) FindLatestPerTask(community string, from, to time.Time) ([]domain.ScanRecord, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	latest := make(map[int64]int)
+	for i := range impl.records {
+		r := &impl.records[i]
+		if r.Community == community && !r.CreatedAt.Before(from) && r.CreatedAt.Before(to) {
+			latest[r.TaskId] = i
+		}
+	}
+
+	records := make([]domain.ScanRecord, 0, len(latest))
+	for i := range impl.records {
+		if j, ok := latest[impl.records[i].TaskId]; ok && i == j {
+			records = append(records, impl.records[i])
+		}
+	}
+
+	return records, nil
+}
@@ -0,0 +1,60 @@
+package fakeimpl
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+)
+
+func NewScanRunImpl() *scanRunImpl {
+	return &scanRunImpl{}
+}
+
+// scanRunImpl 按保存顺序存放，id递增
+type scanRunImpl struct {
+	mu   sync.Mutex
+	runs []domain.ScanRun
+}
+
+func (impl *scanRunImpl) Add(run *domain.ScanRun) error {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	run.Id = int64(len(impl.runs) + 1)
+	impl.runs = append(impl.runs, *run)
+
+	return nil
+}
+
+// List 与数据库实现一致，按开始时间倒序
+func (impl *scanRunImpl) List(opt *repository.ScanRunListOption) ([]domain.ScanRun, int64, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	var runs []domain.ScanRun
+	for i := len(impl.runs) - 1; i >= 0; i-- {
+		if r := impl.runs[i]; matchRun(&r, opt) {
+			runs = append(runs, r)
+		}
+	}
+
+	sortByStartedAt(runs)
+
+	return page(runs, opt.Page, opt.Size), int64(len(runs)), nil
+}
+
+func matchRun(r *domain.ScanRun, opt *repository.ScanRunListOption) bool {
+	return (opt.TaskId == 0 || r.TaskId == opt.TaskId) &&
+		(opt.Community == "" || r.Community == opt.Community) &&
+		(opt.Outcome == "" || r.Outcome == opt.Outcome) &&
+		(opt.From.IsZero() || !r.StartedAt.Before(opt.From)) &&
+		(opt.To.IsZero() || r.StartedAt.Before(opt.To))
+}
+
+func sortByStartedAt(runs []domain.ScanRun) {
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].StartedAt.After(runs[j].StartedAt)
+	})
+}
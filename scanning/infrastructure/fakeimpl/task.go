@@ -0,0 +1,201 @@
+package fakeimpl
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+)
+
+// NewTaskImpl 与数据库实现一致，没有找到时返回gorm.ErrRecordNotFound
+func NewTaskImpl() *taskImpl {
+	return &taskImpl{
+		tasks:  make(map[int64]domain.Task),
+		leases: make(map[int64]lease),
+	}
+}
+
+type taskImpl struct {
+	mu     sync.Mutex
+	tasks  map[int64]domain.Task
+	leases map[int64]lease
+	nextId int64
+}
+
+// Save 与数据库实现一致，不修改暂停状态和执行状态
+func (impl *taskImpl) Save(task domain.Task) error {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	old, ok := impl.tasks[task.Id]
+	task.Paused = ok && old.Paused
+	if ok {
+		task.LastScanTime = old.LastScanTime
+		task.Status = old.Status
+		task.LastError = old.LastError
+		task.Failures = old.Failures
+		task.RetryAt = old.RetryAt
+		task.NextScanTime = old.NextScanTime
+	}
+
+	if task.Id == 0 {
+		impl.nextId++
+		task.Id = impl.nextId
+	}
+
+	impl.tasks[task.Id] = task
+
+	return nil
+}
+
+func (impl *taskImpl) Find(task domain.Task) (domain.Task, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	for _, t := range impl.sorted() {
+		if match(&t, &task) {
+			return t, nil
+		}
+	}
+
+	return domain.Task{}, gorm.ErrRecordNotFound
+}
+
+func match(t, cond *domain.Task) bool {
+	return (cond.Community == "" || cond.Community == t.Community) &&
+		(cond.Registry == nil || t.Registry != nil && cond.Registry.String() == t.Registry.String()) &&
+		(cond.Namespace == "" || cond.Namespace == t.Namespace) &&
+		(cond.Image == "" || cond.Image == t.Image) &&
+		(cond.Tag == "" || cond.Tag == t.Tag)
+}
+
+func (impl *taskImpl) FindById(id int64) (domain.Task, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	t, ok := impl.tasks[id]
+	if !ok {
+		return domain.Task{}, gorm.ErrRecordNotFound
+	}
+
+	return t, nil
+}
+
+func (impl *taskImpl) FindAll(name string) ([]domain.Task, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	var tasks []domain.Task
+	for _, t := range impl.sorted() {
+		if t.Community == name {
+			tasks = append(tasks, t)
+		}
+	}
+
+	return tasks, nil
+}
+
+func (impl *taskImpl) List(opt *repository.TaskListOption) ([]domain.Task, int64, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	cond := domain.Task{
+		Community: opt.Community,
+		Namespace: opt.Namespace,
+		Image:     opt.Image,
+		Tag:       opt.Tag,
+	}
+
+	var tasks []domain.Task
+	for _, t := range impl.sorted() {
+		if !match(&t, &cond) || (opt.Registry != "" && t.Registry.String() != opt.Registry) {
+			continue
+		}
+
+		if opt.Paused != nil && t.Paused != *opt.Paused {
+			continue
+		}
+
+		if opt.Status != "" && t.Status != opt.Status {
+			continue
+		}
+
+		tasks = append(tasks, t)
+	}
+
+	return page(tasks, opt.Page, opt.Size), int64(len(tasks)), nil
+}
+
+func (impl *taskImpl) Reschedule(id int64, next time.Time) error {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	if t, ok := impl.tasks[id]; ok {
+		t.NextScanTime = next
+		impl.tasks[id] = t
+	}
+
+	return nil
+}
+
+func (impl *taskImpl) SetPaused(ids []int64, paused bool) error {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	for _, id := range ids {
+		if t, ok := impl.tasks[id]; ok {
+			t.Paused = paused
+			impl.tasks[id] = t
+		}
+	}
+
+	return nil
+}
+
+func (impl *taskImpl) DeleteByIds(ids []int64) error {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	for _, id := range ids {
+		delete(impl.tasks, id)
+		delete(impl.leases, id)
+	}
+
+	return nil
+}
+
+// sorted 调用方需要持有锁，按id排序与数据库实现保持一致
+func (impl *taskImpl) sorted() []domain.Task {
+	tasks := make([]domain.Task, 0, len(impl.tasks))
+	for _, t := range impl.tasks {
+		tasks = append(tasks, t)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].Id < tasks[j].Id
+	})
+
+	return tasks
+}
+
+// page 与数据库实现一致，page从1开始，size不大于0时返回全部
+func page[T any](items []T, page, size int) []T {
+	if size <= 0 {
+		return items
+	}
+
+	if page < 1 {
+		page = 1
+	}
+
+	start := (page - 1) * size
+	if start >= len(items) {
+		return nil
+	}
+
+	return items[start:min(start+size, len(items))]
+}
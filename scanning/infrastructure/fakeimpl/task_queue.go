@@ -0,0 +1,165 @@
+package fakeimpl
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+)
+
+type lease struct {
+	owner     string
+	until     time.Time
+	requested bool
+}
+
+func (l lease) expired(now time.Time) bool {
+	return l.owner == "" || now.After(l.until)
+}
+
+// due 调用方需要持有锁，不检查社区的暂停状态
+func (impl *taskImpl) due(t *domain.Task, communities, scheduled []string, now time.Time) bool {
+	l := impl.leases[t.Id]
+
+	return slices.Contains(communities, t.Community) && l.expired(now) &&
+		(l.requested || slices.Contains(scheduled, t.Community) && t.IsNeedToScan())
+}
+
+func (impl *taskImpl) Claim(opt *repository.ClaimOption) ([]domain.Task, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	now := time.Now()
+
+	// 与数据库实现的排序一致
+	candidates := impl.sorted()
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := &candidates[i], &candidates[j]
+		if ra, rb := impl.leases[a.Id].requested, impl.leases[b.Id].requested; ra != rb {
+			return ra
+		}
+
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+
+		return a.NextScanTime.Before(b.NextScanTime)
+	})
+
+	var tasks []domain.Task
+	for _, t := range candidates {
+		if len(tasks) >= opt.Limit {
+			break
+		}
+
+		if !impl.due(&t, opt.Communities, opt.Scheduled, now) {
+			continue
+		}
+
+		l := impl.leases[t.Id]
+		l.owner = opt.Owner
+		l.until = now.Add(opt.Lease)
+		impl.leases[t.Id] = l
+
+		tasks = append(tasks, t)
+	}
+
+	return tasks, nil
+}
+
+func (impl *taskImpl) RenewLease(id int64, owner string, d time.Duration) error {
+	return impl.updateLeased(id, owner, func(t *domain.Task, l *lease) {
+		l.until = time.Now().Add(d)
+	})
+}
+
+func (impl *taskImpl) Complete(task domain.Task, owner string) error {
+	return impl.updateLeased(task.Id, owner, func(t *domain.Task, l *lease) {
+		t.LastScanTime = task.LastScanTime
+		t.Status = task.Status
+		t.LastError = task.LastError
+		t.Failures = task.Failures
+		t.RetryAt = task.RetryAt
+		t.NextScanTime = task.NextScanTime
+		*l = lease{}
+	})
+}
+
+func (impl *taskImpl) Release(task domain.Task, owner string) error {
+	return impl.updateLeased(task.Id, owner, func(t *domain.Task, l *lease) {
+		t.Status = task.Status
+		l.owner = ""
+		l.until = time.Time{}
+	})
+}
+
+func (impl *taskImpl) SetStatus(id int64, owner, status string) error {
+	return impl.updateLeased(id, owner, func(t *domain.Task, l *lease) {
+		t.Status = status
+	})
+}
+
+func (impl *taskImpl) updateLeased(id int64, owner string, update func(*domain.Task, *lease)) error {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	t, ok := impl.tasks[id]
+	l := impl.leases[id]
+	if !ok || l.owner != owner {
+		return repository.ErrLeaseLost
+	}
+
+	update(&t, &l)
+	impl.tasks[id] = t
+	impl.leases[id] = l
+
+	return nil
+}
+
+func (impl *taskImpl) RequestScan(id int64) error {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	if _, ok := impl.tasks[id]; ok {
+		l := impl.leases[id]
+		l.requested = true
+		impl.leases[id] = l
+	}
+
+	return nil
+}
+
+func (impl *taskImpl) CountRunning(communities []string) (map[string]int, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	now := time.Now()
+
+	result := make(map[string]int, len(communities))
+	for id, l := range impl.leases {
+		t, ok := impl.tasks[id]
+		if ok && !l.expired(now) && slices.Contains(communities, t.Community) {
+			result[t.Community]++
+		}
+	}
+
+	return result, nil
+}
+
+func (impl *taskImpl) CountDue(communities, scheduled []string) (int64, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	now := time.Now()
+
+	var total int64
+	for _, t := range impl.tasks {
+		if impl.due(&t, communities, scheduled, now) {
+			total++
+		}
+	}
+
+	return total, nil
+}
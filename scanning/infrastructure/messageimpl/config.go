@@ -0,0 +1,22 @@
+package messageimpl
+
+const (
+	defaultTopic  = "image_scanning_event"
+	defaultSource = "https://github.com/opensourceways/image-scanning"
+)
+
+// Config 生命周期事件以CloudEvents格式发布到Topic，Source为事件的source属性
+type Config struct {
+	Topic  string `json:"topic"`
+	Source string `json:"source"`
+}
+
+func (cfg *Config) SetDefault() {
+	if cfg.Topic == "" {
+		cfg.Topic = defaultTopic
+	}
+
+	if cfg.Source == "" {
+		cfg.Source = defaultSource
+	}
+}
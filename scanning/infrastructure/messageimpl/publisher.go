@@ -0,0 +1,65 @@
+package messageimpl
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/opensourceways/image-scanning/common/infrastructure/kafka"
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+const (
+	cloudEventsSpecVersion = "1.0"
+	cloudEventsTypePrefix  = "org.opensourceways.image-scanning."
+	cloudEventsContentType = "application/cloudevents+json; charset=UTF-8"
+	dataContentType        = "application/json"
+)
+
+// cloudEvent CloudEvents 1.0 structured mode
+type cloudEvent struct {
+	SpecVersion     string               `json:"specversion"`
+	Id              string               `json:"id"`
+	Source          string               `json:"source"`
+	Type            string               `json:"type"`
+	Subject         string               `json:"subject,omitempty"`
+	Time            string               `json:"time"`
+	DataContentType string               `json:"datacontenttype"`
+	Data            domain.LifecycleData `json:"data"`
+}
+
+func NewPublisherImpl(cfg *Config) *publisherImpl {
+	return &publisherImpl{cfg: *cfg}
+}
+
+type publisherImpl struct {
+	cfg Config
+}
+
+func (impl *publisherImpl) Publish(e domain.LifecycleEvent) error {
+	if !kafka.Enabled() {
+		return nil
+	}
+
+	body, err := json.Marshal(cloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		Id:              uuid.NewString(),
+		Source:          impl.cfg.Source,
+		Type:            cloudEventsTypePrefix + e.Type,
+		Subject:         e.Subject,
+		Time:            e.Time.UTC().Format(time.RFC3339Nano),
+		DataContentType: dataContentType,
+		Data:            e.Data,
+	})
+	if err != nil {
+		return err
+	}
+
+	header := map[string]string{
+		"content-type": cloudEventsContentType,
+	}
+
+	// 同一个任务的事件使用相同的key，保证消费时的顺序
+	return kafka.Publish(impl.cfg.Topic, e.Subject, header, body)
+}
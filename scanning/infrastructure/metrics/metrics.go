@@ -0,0 +1,183 @@
+// Package metrics 扫描流程的prometheus指标
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	namespace = "image_scanning"
+
+	StagePull   = "pull"
+	StageScan   = "scan"
+	StageUpload = "upload"
+
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+var durationBuckets = []float64{5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600}
+
+var (
+	tasks = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "tasks",
+		Help:      "Number of scan tasks per community.",
+	}, []string{"community", "paused"})
+
+	taskStatuses = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "task_statuses",
+		Help:      "Number of scan tasks per community by execution status.",
+	}, []string{"community", "status"})
+
+	scansInProgress = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "scans_in_progress",
+		Help:      "Number of scans currently running.",
+	}, []string{"community"})
+
+	scanDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "scan_duration_seconds",
+		Help:      "Duration of a whole task scan including pull, trivy and upload.",
+		Buckets:   durationBuckets,
+	}, []string{"community", "result"})
+
+	pullDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "pull_duration_seconds",
+		Help:      "Duration of pulling a single arch of an image.",
+		Buckets:   durationBuckets,
+	}, []string{"registry", "result"})
+
+	registryThrottled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registry_throttled_total",
+		Help:      "Number of times a registry throttled our requests or pulls.",
+	}, []string{"registry"})
+
+	failures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "failures_total",
+		Help:      "Number of scan failures by stage.",
+	}, []string{"community", "stage"})
+
+	lastScan = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_scan_timestamp_seconds",
+		Help:      "Unix time of the last finished scan per community.",
+	}, []string{"community"})
+
+	cronLastRun = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cron_last_run_timestamp_seconds",
+		Help:      "Unix time of the last run of each cron job.",
+	}, []string{"job"})
+
+	cronLastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cron_last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful run of each cron job.",
+	}, []string{"job"})
+
+	cronDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "cron_duration_seconds",
+		Help:      "Duration of each cron job run.",
+		Buckets:   durationBuckets,
+	}, []string{"job"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		tasks, taskStatuses, scansInProgress, scanDuration, pullDuration, registryThrottled, failures, lastScan,
+		cronLastRun, cronLastSuccess, cronDuration,
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// SetTasks total为社区任务总数，paused为其中暂停的任务数
+func SetTasks(community string, total, paused int) {
+	tasks.WithLabelValues(community, "false").Set(float64(total - paused))
+	tasks.WithLabelValues(community, "true").Set(float64(paused))
+}
+
+// SetTaskStatuses statuses需要包含所有状态，没有任务的状态也要置0
+func SetTaskStatuses(community string, statuses map[string]int) {
+	for status, n := range statuses {
+		taskStatuses.WithLabelValues(community, status).Set(float64(n))
+	}
+}
+
+// ScanStarted 返回扫描结束时调用的函数
+func ScanStarted(community string) func(success bool) {
+	start := time.Now()
+	scansInProgress.WithLabelValues(community).Inc()
+
+	return func(success bool) {
+		scansInProgress.WithLabelValues(community).Dec()
+		scanDuration.WithLabelValues(community, result(success)).Observe(time.Since(start).Seconds())
+		lastScan.WithLabelValues(community).SetToCurrentTime()
+	}
+}
+
+func ObservePull(registry string, start time.Time, success bool) {
+	pullDuration.WithLabelValues(registry, result(success)).Observe(time.Since(start).Seconds())
+}
+
+func IncThrottled(registry string) {
+	registryThrottled.WithLabelValues(registry).Inc()
+}
+
+func IncFailure(community, stage string) {
+	failures.WithLabelValues(community, stage).Inc()
+}
+
+// CronJob 记录定时任务的运行时间，返回错误或者panic时不更新最近成功时间
+func CronJob(job string, run func() error) func() {
+	return func() {
+		start := time.Now()
+		success := false
+
+		defer func() {
+			cronLastRun.WithLabelValues(job).Set(float64(start.Unix()))
+			cronDuration.WithLabelValues(job).Observe(time.Since(start).Seconds())
+
+			if success {
+				cronLastSuccess.WithLabelValues(job).SetToCurrentTime()
+			}
+		}()
+
+		success = run() == nil
+	}
+}
+
+// RegisterGaugeFunc 注册在采集时计算的指标，例如队列长度
+func RegisterGaugeFunc(name, help string, labels prometheus.Labels, f func() float64) {
+	err := prometheus.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        name,
+		Help:        help,
+		ConstLabels: labels,
+	}, f))
+	if err != nil {
+		logrus.Errorf("register metric %s failed: %s", name, err.Error())
+	}
+}
+
+func result(success bool) string {
+	if success {
+		return ResultSuccess
+	}
+
+	return ResultFailure
+}
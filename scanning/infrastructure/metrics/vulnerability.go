@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+// vulnerabilityCacheTTL 统计需要查询数据库，缓存一段时间避免每次采集都查询
+const vulnerabilityCacheTTL = time.Minute
+
+// RegisterVulnerabilityCollector load返回每个社区各个严重级别的漏洞数量
+func RegisterVulnerabilityCollector(load func() ([]domain.SeverityCount, error)) {
+	c := &vulnerabilityCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "vulnerabilities"),
+			"Number of vulnerabilities in the latest scan results by community and severity.",
+			[]string{"community", "severity"}, nil,
+		),
+		load: load,
+	}
+
+	if err := prometheus.Register(c); err != nil {
+		logrus.Errorf("register vulnerability collector failed: %s", err.Error())
+	}
+}
+
+type vulnerabilityCollector struct {
+	desc *prometheus.Desc
+	load func() ([]domain.SeverityCount, error)
+
+	mu        sync.Mutex
+	counts    []domain.SeverityCount
+	updatedAt time.Time
+}
+
+func (c *vulnerabilityCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *vulnerabilityCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, v := range c.get() {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(v.Count), v.Community, v.Severity)
+	}
+}
+
+// get 查询失败时使用上一次的结果
+func (c *vulnerabilityCollector) get() []domain.SeverityCount {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.updatedAt) < vulnerabilityCacheTTL {
+		return c.counts
+	}
+
+	counts, err := c.load()
+	if err != nil {
+		logrus.Errorf("count vulnerabilities failed: %s", err.Error())
+		return c.counts
+	}
+
+	c.counts = counts
+	c.updatedAt = time.Now()
+
+	return counts
+}
@@ -0,0 +1,227 @@
+package notifierimpl
+
+import (
+	"bytes"
+	"crypto/tls"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+const (
+	smtpsPort       = 465
+	dialTimeout     = 30 * time.Second
+	maxDigestRows   = 200
+	digestTimeStyle = time.DateTime
+)
+
+//go:embed templates
+var templates embed.FS
+
+var (
+	templateFuncs = map[string]interface{}{
+		"date": func(t time.Time) string {
+			return t.Format(digestTimeStyle)
+		},
+	}
+
+	digestTextTpl = texttemplate.Must(
+		texttemplate.New("digest.txt").Funcs(templateFuncs).ParseFS(templates, "templates/digest.txt"),
+	)
+
+	digestHtmlTpl = htmltemplate.Must(
+		htmltemplate.New("digest.html").Funcs(templateFuncs).ParseFS(templates, "templates/digest.html"),
+	)
+)
+
+func NewEmailSender(cfg *domain.EmailDigest) *emailImpl {
+	return &emailImpl{cfg: cfg}
+}
+
+type emailImpl struct {
+	cfg *domain.EmailDigest
+}
+
+// digestView 邮件中每一类最多展示maxDigestRows行
+type digestView struct {
+	domain.Digest
+
+	TotalNew        int
+	TotalFixed      int
+	TotalFailing    int
+	TotalViolations int
+}
+
+func newDigestView(d *domain.Digest) digestView {
+	view := digestView{
+		Digest:          *d,
+		TotalNew:        len(d.NewFindings),
+		TotalFixed:      len(d.FixedFindings),
+		TotalFailing:    len(d.FailingImages),
+		TotalViolations: len(d.PolicyViolations),
+	}
+
+	view.NewFindings = view.NewFindings[:min(len(view.NewFindings), maxDigestRows)]
+	view.FixedFindings = view.FixedFindings[:min(len(view.FixedFindings), maxDigestRows)]
+
+	return view
+}
+
+func (impl *emailImpl) SendDigest(d *domain.Digest) error {
+	subject := fmt.Sprintf("[%s] 镜像扫描汇总 %s", d.Community, d.Until.Format(time.DateOnly))
+
+	msg, err := impl.buildMessage(subject, newDigestView(d))
+	if err != nil {
+		return err
+	}
+
+	return impl.send(msg)
+}
+
+// buildMessage 生成同时包含纯文本和HTML的multipart/alternative邮件
+func (impl *emailImpl) buildMessage(subject string, view digestView) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	var text bytes.Buffer
+	if err := digestTextTpl.Execute(&text, view); err != nil {
+		return nil, err
+	}
+
+	if err := writePart(mw, "text/plain; charset=UTF-8", text.Bytes()); err != nil {
+		return nil, err
+	}
+
+	var html bytes.Buffer
+	if err := digestHtmlTpl.Execute(&html, view); err != nil {
+		return nil, err
+	}
+
+	if err := writePart(mw, "text/html; charset=UTF-8", html.Bytes()); err != nil {
+		return nil, err
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	headers := []string{
+		"From: " + impl.cfg.From,
+		"To: " + strings.Join(impl.cfg.To, ", "),
+		"Subject: " + mime.BEncoding.Encode("UTF-8", subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + mw.Boundary(),
+	}
+
+	var msg bytes.Buffer
+	msg.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+	msg.Write(body.Bytes())
+
+	return msg.Bytes(), nil
+}
+
+func writePart(mw *multipart.Writer, contentType string, content []byte) error {
+	w, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+
+	qw := quotedprintable.NewWriter(w)
+	if _, err = qw.Write(content); err != nil {
+		return err
+	}
+
+	return qw.Close()
+}
+
+func (impl *emailImpl) send(msg []byte) error {
+	c, err := impl.dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if impl.cfg.Username != "" {
+		auth := smtp.PlainAuth("", impl.cfg.Username, impl.cfg.Password, impl.cfg.Host)
+		if err = c.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err = c.Mail(impl.cfg.From); err != nil {
+		return err
+	}
+
+	for _, to := range impl.cfg.To {
+		if err = c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(w, bytes.NewReader(msg)); err != nil {
+		return err
+	}
+
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// dial 465端口直接使用SSL连接，其他端口在服务端支持时升级为STARTTLS
+func (impl *emailImpl) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(impl.cfg.Host, strconv.Itoa(impl.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: impl.cfg.Host, MinVersion: tls.VersionTLS12}
+
+	if impl.cfg.Port == smtpsPort {
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, "tcp", addr, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		return smtp.NewClient(conn, impl.cfg.Host)
+	}
+
+	conn, err := net.DialTimeout("tcp", addr, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := smtp.NewClient(conn, impl.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(tlsConfig); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
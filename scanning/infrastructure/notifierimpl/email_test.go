@@ -0,0 +1,213 @@
+package notifierimpl
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+func testDigest(newFindings int) domain.Digest {
+	d := domain.Digest{
+		Community:     "openeuler",
+		Since:         time.Date(2024, 6, 1, 9, 0, 0, 0, time.Local),
+		Until:         time.Date(2024, 6, 2, 9, 0, 0, 0, time.Local),
+		ScannedImages: 3,
+		FailingImages: []domain.DigestImage{{Image: "docker.io/openeuler/openeuler:22.03", Details: []string{"pull timeout"}}},
+	}
+
+	for i := 0; i < newFindings; i++ {
+		d.NewFindings = append(d.NewFindings, domain.DigestFinding{
+			Image: "docker.io/openeuler/openeuler:24.03",
+			Arch:  "amd64",
+			Vulnerability: domain.Vulnerability{
+				VulnerabilityID: fmt.Sprintf("CVE-2024-%04d", i),
+				PkgName:         "openssl",
+				Severity:        domain.SeverityHigh,
+			},
+		})
+	}
+
+	return d
+}
+
+// parseMessage 解析邮件，返回头部和各部分解码后的内容
+func parseMessage(t *testing.T, raw []byte) (mail.Header, map[string]string) {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("unexpected content type %s: %v", msg.Header.Get("Content-Type"), err)
+	}
+
+	parts := make(map[string]string)
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		// NextPart会解码quoted-printable
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[partType] = string(body)
+	}
+
+	return msg.Header, parts
+}
+
+func TestBuildMessage(t *testing.T) {
+	impl := NewEmailSender(&domain.EmailDigest{From: "scanner@example.com", To: []string{"a@example.com", "b@example.com"}})
+
+	d := testDigest(maxDigestRows + 1)
+	raw, err := impl.buildMessage("[openeuler] 镜像扫描汇总", newDigestView(&d))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, parts := parseMessage(t, raw)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(header.Get("Subject"))
+	if err != nil || subject != "[openeuler] 镜像扫描汇总" {
+		t.Errorf("unexpected subject %q: %v", subject, err)
+	}
+
+	if to, err := header.AddressList("To"); err != nil || len(to) != 2 {
+		t.Errorf("unexpected recipients %v: %v", to, err)
+	}
+
+	text := parts["text/plain"]
+	for _, want := range []string{
+		"统计时间：2024-06-01 09:00:00 ~ 2024-06-02 09:00:00",
+		fmt.Sprintf("新增漏洞（%d）", maxDigestRows+1),
+		fmt.Sprintf("...仅展示前%d项", maxDigestRows),
+		"CVE-2024-0000 openssl",
+		"扫描失败的镜像（1）",
+		"pull timeout",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text part should contain %q", want)
+		}
+	}
+
+	if strings.Contains(text, fmt.Sprintf("CVE-2024-%04d", maxDigestRows)) {
+		t.Error("text part should be truncated")
+	}
+
+	html := parts["text/html"]
+	if !strings.Contains(html, "CVE-2024-0000") || !strings.Contains(html, "openeuler") {
+		t.Errorf("unexpected html part:\n%s", html)
+	}
+}
+
+// smtpSink 只实现发送邮件需要的命令，不支持STARTTLS和认证
+func smtpSink(t *testing.T) (int, <-chan []byte) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan []byte, 1)
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { _, _ = io.WriteString(conn, s+"\r\n") }
+
+		reply("220 sink ready")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			switch cmd := strings.ToUpper(strings.Fields(line + " ")[0]); cmd {
+			case "EHLO", "HELO", "MAIL", "RCPT":
+				reply("250 ok")
+			case "DATA":
+				reply("354 go ahead")
+
+				var data bytes.Buffer
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+
+					data.WriteString(strings.TrimPrefix(l, "."))
+				}
+
+				received <- data.Bytes()
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+
+	return ln.Addr().(*net.TCPAddr).Port, received
+}
+
+func TestSendDigest(t *testing.T) {
+	port, received := smtpSink(t)
+
+	impl := NewEmailSender(&domain.EmailDigest{
+		Host: "127.0.0.1",
+		Port: port,
+		From: "scanner@example.com",
+		To:   []string{"a@example.com"},
+	})
+
+	d := testDigest(1)
+	if err := impl.SendDigest(&d); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case raw := <-received:
+		header, parts := parseMessage(t, raw)
+
+		subject, _ := new(mime.WordDecoder).DecodeHeader(header.Get("Subject"))
+		if subject != "[openeuler] 镜像扫描汇总 2024-06-02" {
+			t.Errorf("unexpected subject %q", subject)
+		}
+
+		if !strings.Contains(parts["text/plain"], "CVE-2024-0000") {
+			t.Errorf("unexpected text part:\n%s", parts["text/plain"])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no mail received")
+	}
+}
@@ -0,0 +1,104 @@
+package notifierimpl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+type feishuMessage struct {
+	Timestamp string `json:"timestamp,omitempty"`
+	Sign      string `json:"sign,omitempty"`
+	MsgType   string `json:"msg_type"`
+	Content   struct {
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+// feishuRequest reference: https://open.feishu.cn/document/client-docs/bot-v3/add-custom-bot
+func feishuRequest(hook *domain.Webhook, e *domain.Event, text string) (*http.Request, error) {
+	msg := feishuMessage{MsgType: "text"}
+	msg.Content.Text = text
+
+	if hook.Secret != "" {
+		msg.Timestamp = strconv.FormatInt(time.Now().Unix(), 10)
+		// 飞书使用 timestamp+"\n"+secret 作为密钥对空字符串签名
+		mac := hmac.New(sha256.New, []byte(msg.Timestamp+"\n"+hook.Secret))
+		msg.Sign = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	req, _, err := newJSONRequest(hook.Url, msg)
+
+	return req, err
+}
+
+type dingTalkMessage struct {
+	MsgType  string `json:"msgtype"`
+	Markdown struct {
+		Title string `json:"title"`
+		Text  string `json:"text"`
+	} `json:"markdown"`
+}
+
+// dingTalkRequest reference: https://open.dingtalk.com/document/robots/customize-robot-security-settings
+func dingTalkRequest(hook *domain.Webhook, e *domain.Event, text string) (*http.Request, error) {
+	msg := dingTalkMessage{MsgType: "markdown"}
+	msg.Markdown.Title = e.Title()
+	msg.Markdown.Text = text
+
+	u := hook.Url
+	if hook.Secret != "" {
+		parsed, err := url.Parse(hook.Url)
+		if err != nil {
+			return nil, err
+		}
+
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		mac := hmac.New(sha256.New, []byte(hook.Secret))
+		mac.Write([]byte(timestamp + "\n" + hook.Secret))
+
+		query := parsed.Query()
+		query.Set("timestamp", timestamp)
+		query.Set("sign", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+		parsed.RawQuery = query.Encode()
+		u = parsed.String()
+	}
+
+	req, _, err := newJSONRequest(u, msg)
+
+	return req, err
+}
+
+type weComMessage struct {
+	MsgType  string `json:"msgtype"`
+	Markdown struct {
+		Content string `json:"content"`
+	} `json:"markdown"`
+}
+
+// weComRequest reference: https://developer.work.weixin.qq.com/document/path/91770
+func weComRequest(hook *domain.Webhook, e *domain.Event, text string) (*http.Request, error) {
+	msg := weComMessage{MsgType: "markdown"}
+	msg.Markdown.Content = text
+
+	req, _, err := newJSONRequest(hook.Url, msg)
+
+	return req, err
+}
+
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// slackRequest reference: https://api.slack.com/messaging/webhooks
+func slackRequest(hook *domain.Webhook, e *domain.Event, text string) (*http.Request, error) {
+	req, _, err := newJSONRequest(hook.Url, slackMessage{Text: text})
+
+	return req, err
+}
@@ -0,0 +1,209 @@
+package notifierimpl
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+const (
+	requestTimeout   = 10 * time.Second
+	maxDetails       = 20
+	maxResponseBytes = 1 << 20
+
+	headerSignature = "X-Signature-256"
+)
+
+var messageTpl = template.Must(template.New("message").Parse(
+	`【{{.Title}}】
+{{.Summary}}
+{{- if .Community}}
+社区：{{.Community}}
+{{- end}}
+{{- if .Sig}}
+SIG：{{.Sig}}
+{{- end}}
+{{- range .Details}}
+- {{.}}
+{{- end}}
+{{- if .Mentions}}
+负责人：{{range .Mentions}}@{{.}} {{end}}
+{{- end}}
+`))
+
+// requestBuilder 按照各个平台的格式构造请求
+type requestBuilder func(hook *domain.Webhook, e *domain.Event, text string) (*http.Request, error)
+
+var builders = map[string]requestBuilder{
+	domain.WebhookTypeGeneric:  genericRequest,
+	domain.WebhookTypeFeishu:   feishuRequest,
+	domain.WebhookTypeDingTalk: dingTalkRequest,
+	domain.WebhookTypeWeCom:    weComRequest,
+	domain.WebhookTypeSlack:    slackRequest,
+}
+
+func NewNotifier(hooks []domain.Webhook) *notifierImpl {
+	return newNotifier(hooks, &http.Client{Timeout: requestTimeout})
+}
+
+func newNotifier(hooks []domain.Webhook, client *http.Client) *notifierImpl {
+	impl := &notifierImpl{client: client}
+	for _, hook := range hooks {
+		if _, ok := builders[hook.Type]; !ok {
+			logrus.Errorf("unsupported webhook type %s", hook.Type)
+			continue
+		}
+
+		impl.hooks = append(impl.hooks, hook)
+	}
+
+	return impl
+}
+
+type notifierImpl struct {
+	client *http.Client
+	hooks  []domain.Webhook
+}
+
+func (impl *notifierImpl) Notify(e domain.Event) error {
+	var errs []error
+	for i := range impl.hooks {
+		hook := &impl.hooks[i]
+		if !hook.Subscribe(&e) {
+			continue
+		}
+
+		if err := impl.send(hook, &e); err != nil {
+			errs = append(errs, fmt.Errorf("send %s to %s webhook failed: %w", e.Type, hook.Type, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (impl *notifierImpl) send(hook *domain.Webhook, e *domain.Event) error {
+	text, err := renderMessage(e)
+	if err != nil {
+		return err
+	}
+
+	req, err := builders[hook.Type](hook, e, text)
+	if err != nil {
+		return err
+	}
+
+	resp, err := impl.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp)
+}
+
+// renderMessage 漏洞较多时只展示部分详情
+func renderMessage(e *domain.Event) (string, error) {
+	view := *e
+	if len(view.Details) > maxDetails {
+		view.Details = append(view.Details[:maxDetails:maxDetails],
+			fmt.Sprintf("...等%d项", len(e.Details)))
+	}
+
+	var buf strings.Builder
+	if err := messageTpl.Execute(&buf, &view); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// webhookResponse 飞书返回code，钉钉和企业微信返回errcode，非0表示失败
+type webhookResponse struct {
+	Code    *int   `json:"code"`
+	Msg     string `json:"msg"`
+	ErrCode *int   `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func checkResponse(resp *http.Response) error {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+
+	var r webhookResponse
+	if err = json.Unmarshal(body, &r); err != nil {
+		// slack等平台成功时返回的不是json
+		return nil
+	}
+
+	if r.Code != nil && *r.Code != 0 {
+		return fmt.Errorf("code %d: %s", *r.Code, r.Msg)
+	}
+
+	if r.ErrCode != nil && *r.ErrCode != 0 {
+		return fmt.Errorf("errcode %d: %s", *r.ErrCode, r.ErrMsg)
+	}
+
+	return nil
+}
+
+func newJSONRequest(url string, payload interface{}) (*http.Request, []byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	return req, body, nil
+}
+
+type genericMessage struct {
+	domain.Event
+
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// genericRequest 配置了secret时，使用HMAC-SHA256对请求体签名，放在X-Signature-256头中
+func genericRequest(hook *domain.Webhook, e *domain.Event, text string) (*http.Request, error) {
+	req, body, err := newJSONRequest(hook.Url, genericMessage{
+		Event: *e,
+		Title: e.Title(),
+		Text:  text,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if hook.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(hook.Secret))
+		mac.Write(body)
+		req.Header.Set(headerSignature, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	return req, nil
+}
@@ -0,0 +1,233 @@
+package notifierimpl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+// received 测试服务器收到的请求
+type received struct {
+	header http.Header
+	query  url.Values
+	body   []byte
+}
+
+func newTestServer(t *testing.T, response string) (*httptest.Server, func() []received) {
+	var mu sync.Mutex
+	var reqs []received
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		reqs = append(reqs, received{header: r.Header.Clone(), query: r.URL.Query(), body: body})
+		mu.Unlock()
+
+		_, _ = io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []received {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]received(nil), reqs...)
+	}
+}
+
+func testEvent() domain.Event {
+	return domain.Event{
+		Type:      domain.EventNewFindings,
+		Community: "openeuler",
+		Image:     "docker.io/openeuler/openeuler:24.03",
+		Summary:   "发现2个新的高危漏洞",
+		Details:   []string{"CVE-2024-0001", "CVE-2024-0002"},
+	}
+}
+
+// notifyOne 向单个webhook发送事件，返回收到的唯一请求
+func notifyOne(t *testing.T, hook domain.Webhook, response string) received {
+	t.Helper()
+
+	server, reqs := newTestServer(t, response)
+	hook.Url = server.URL + "/hook?token=abc"
+
+	if err := newNotifier([]domain.Webhook{hook}, server.Client()).Notify(testEvent()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := reqs()
+	if len(got) != 1 {
+		t.Fatalf("want 1 request, got %d", len(got))
+	}
+
+	return got[0]
+}
+
+func TestGenericSignature(t *testing.T) {
+	r := notifyOne(t, domain.Webhook{Type: domain.WebhookTypeGeneric, Secret: "secret"}, "")
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(r.body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); r.header.Get(headerSignature) != want {
+		t.Errorf("want signature %s, got %s", want, r.header.Get(headerSignature))
+	}
+
+	var msg map[string]interface{}
+	if err := json.Unmarshal(r.body, &msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg["type"] != domain.EventNewFindings || msg["image"] != testEvent().Image || msg["title"] != "镜像扫描发现新的高危漏洞" {
+		t.Errorf("unexpected payload: %s", r.body)
+	}
+
+	r = notifyOne(t, domain.Webhook{Type: domain.WebhookTypeGeneric}, "")
+	if v := r.header.Get(headerSignature); v != "" {
+		t.Errorf("no signature expected without secret, got %s", v)
+	}
+}
+
+func TestFeishuPayload(t *testing.T) {
+	r := notifyOne(t, domain.Webhook{Type: domain.WebhookTypeFeishu, Secret: "secret"}, `{"code":0}`)
+
+	var msg feishuMessage
+	if err := json.Unmarshal(r.body, &msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.MsgType != "text" || !strings.Contains(msg.Content.Text, "CVE-2024-0002") {
+		t.Errorf("unexpected payload: %s", r.body)
+	}
+
+	mac := hmac.New(sha256.New, []byte(msg.Timestamp+"\n"+"secret"))
+	if want := base64.StdEncoding.EncodeToString(mac.Sum(nil)); msg.Timestamp == "" || msg.Sign != want {
+		t.Errorf("want sign %s, got %s at %s", want, msg.Sign, msg.Timestamp)
+	}
+}
+
+func TestDingTalkPayload(t *testing.T) {
+	r := notifyOne(t, domain.Webhook{Type: domain.WebhookTypeDingTalk, Secret: "secret"}, `{"errcode":0}`)
+
+	var msg dingTalkMessage
+	if err := json.Unmarshal(r.body, &msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.MsgType != "markdown" || msg.Markdown.Title != "镜像扫描发现新的高危漏洞" ||
+		!strings.Contains(msg.Markdown.Text, "社区：openeuler") {
+		t.Errorf("unexpected payload: %s", r.body)
+	}
+
+	// 原有的查询参数需要保留
+	timestamp := r.query.Get("timestamp")
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(timestamp + "\n" + "secret"))
+	if want := base64.StdEncoding.EncodeToString(mac.Sum(nil)); r.query.Get("sign") != want || r.query.Get("token") != "abc" {
+		t.Errorf("unexpected query: %v", r.query)
+	}
+}
+
+func TestWeComAndSlackPayload(t *testing.T) {
+	r := notifyOne(t, domain.Webhook{Type: domain.WebhookTypeWeCom}, `{"errcode":0,"errmsg":"ok"}`)
+
+	var wecom weComMessage
+	if err := json.Unmarshal(r.body, &wecom); err != nil {
+		t.Fatal(err)
+	}
+
+	if wecom.MsgType != "markdown" || !strings.HasPrefix(wecom.Markdown.Content, "【镜像扫描发现新的高危漏洞】") {
+		t.Errorf("unexpected wecom payload: %s", r.body)
+	}
+
+	r = notifyOne(t, domain.Webhook{Type: domain.WebhookTypeSlack}, "ok")
+
+	var slack slackMessage
+	if err := json.Unmarshal(r.body, &slack); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(slack.Text, "- CVE-2024-0001") {
+		t.Errorf("unexpected slack payload: %s", r.body)
+	}
+}
+
+func TestNotifyErrors(t *testing.T) {
+	cases := []struct {
+		hookType string
+		response string
+	}{
+		{domain.WebhookTypeFeishu, `{"code":19021,"msg":"sign match fail"}`},
+		{domain.WebhookTypeDingTalk, `{"errcode":310000,"errmsg":"sign not match"}`},
+		{domain.WebhookTypeWeCom, `{"errcode":93000,"errmsg":"invalid webhook url"}`},
+	}
+
+	for _, c := range cases {
+		server, _ := newTestServer(t, c.response)
+		hooks := []domain.Webhook{{Type: c.hookType, Url: server.URL}}
+
+		if err := newNotifier(hooks, server.Client()).Notify(testEvent()); err == nil {
+			t.Errorf("%s: error response %s should fail", c.hookType, c.response)
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	hooks := []domain.Webhook{{Type: domain.WebhookTypeSlack, Url: server.URL}}
+	if err := newNotifier(hooks, server.Client()).Notify(testEvent()); err == nil {
+		t.Error("status 500 should fail")
+	}
+}
+
+func TestNotifySubscription(t *testing.T) {
+	server, reqs := newTestServer(t, "")
+	hooks := []domain.Webhook{
+		{Type: domain.WebhookTypeGeneric, Url: server.URL, Events: []string{domain.EventScanFailed}},
+		{Type: "unknown", Url: server.URL},
+	}
+
+	if err := newNotifier(hooks, server.Client()).Notify(testEvent()); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(reqs()); n != 0 {
+		t.Errorf("unsubscribed or unsupported webhook should not be called, got %d requests", n)
+	}
+}
+
+func TestRenderMessageTruncatesDetails(t *testing.T) {
+	e := testEvent()
+	e.Details = nil
+	for i := 0; i < maxDetails+5; i++ {
+		e.Details = append(e.Details, fmt.Sprintf("CVE-%d", i))
+	}
+
+	text, err := renderMessage(&e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(text, fmt.Sprintf("CVE-%d\n", maxDetails)) || !strings.Contains(text, fmt.Sprintf("...等%d项", maxDetails+5)) {
+		t.Errorf("details should be truncated:\n%s", text)
+	}
+
+	if len(e.Details) != maxDetails+5 {
+		t.Error("the event should not be modified")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"path"
 	"strings"
 
+	sdk "github.com/opensourceways/go-gitee/gitee"
 	"github.com/opensourceways/robot-gitee-lib/client"
 	"sigs.k8s.io/yaml"
 
@@ -14,6 +15,7 @@ import (
 const (
 	uploadDefaultBranchOfGitee = "master"
 	uploadDefaultCommitMsg     = "image scanning result"
+	issueStateOpen             = "open"
 )
 
 func NewGiteeImpl(c *domain.Community) *giteeImpl {
@@ -56,6 +58,17 @@ func (impl *giteeImpl) DownloadScanConfig() (scanConfig domain.ScanConfig, sha s
 	return
 }
 
+// DownloadConfigFile 下载扫描配置文件所在仓库中的其他文件
+func (impl *giteeImpl) DownloadConfigFile(filePath string) ([]byte, error) {
+	scl := impl.community.ScanConfigLocation
+	content, err := impl.client.GetPathContent(impl.community.Name, scl.Repo, filePath, scl.Ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(content.Content)
+}
+
 func (impl *giteeImpl) Upload(content, mdPath string) error {
 	var fileIsNotExist bool
 	repoName := impl.output.GetRepoName()
@@ -79,3 +92,41 @@ func (impl *giteeImpl) Upload(content, mdPath string) error {
 
 	return err
 }
+
+// CreateIssue 返回新建issue的编号，设置标签或者负责人失败时issue已经创建，同样返回编号
+func (impl *giteeImpl) CreateIssue(repo string, content domain.IssueContent) (string, error) {
+	issue, err := impl.client.CreateIssue(impl.community.Name, repo, content.Title, content.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if len(content.Labels) > 0 {
+		err = impl.client.AddMultiIssueLabel(impl.community.Name, repo, issue.Number, content.Labels)
+		if err != nil {
+			return issue.Number, err
+		}
+	}
+
+	// gitee的issue只能指定一个负责人
+	if len(content.Assignees) > 0 {
+		err = impl.client.AssignGiteeIssue(impl.community.Name, repo, issue.Number, content.Assignees[0])
+	}
+
+	return issue.Number, err
+}
+
+// UpdateIssue 更新issue内容，issue已关闭时重新打开
+func (impl *giteeImpl) UpdateIssue(repo, number string, content domain.IssueContent) error {
+	_, err := impl.client.UpdateIssue(impl.community.Name, number, sdk.IssueUpdateParam{
+		Repo:  repo,
+		Title: content.Title,
+		Body:  content.Body,
+		State: issueStateOpen,
+	})
+
+	return err
+}
+
+func (impl *giteeImpl) CloseIssue(repo, number string) error {
+	return impl.client.CloseIssue(impl.community.Name, repo, number)
+}
@@ -3,8 +3,10 @@ package platformimpl
 import (
 	"encoding/base64"
 	"path"
+	"strconv"
 	"strings"
 
+	sdk "github.com/google/go-github/v36/github"
 	"github.com/opensourceways/robot-github-lib/client"
 	"sigs.k8s.io/yaml"
 
@@ -56,6 +58,17 @@ func (impl *githubImpl) DownloadScanConfig() (scanConfig domain.ScanConfig, sha
 	return
 }
 
+// DownloadConfigFile 下载扫描配置文件所在仓库中的其他文件
+func (impl *githubImpl) DownloadConfigFile(filePath string) ([]byte, error) {
+	scl := impl.community.ScanConfigLocation
+	content, err := impl.client.GetPathContent(impl.community.Name, scl.Repo, filePath, scl.Ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(strings.ReplaceAll(*content.Content, "\n", ""))
+}
+
 func (impl *githubImpl) Upload(content, mdPath string) error {
 	repoName := impl.output.GetRepoName()
 	filePath := path.Join(impl.output.Path, mdPath)
@@ -74,3 +87,59 @@ func (impl *githubImpl) Upload(content, mdPath string) error {
 		uploadDefaultBranchOfGithub, uploadDefaultCommitMsg, sha, []byte(content),
 	)
 }
+
+func (impl *githubImpl) CreateIssue(repo string, content domain.IssueContent) (string, error) {
+	req := &sdk.IssueRequest{
+		Title: &content.Title,
+		Body:  &content.Body,
+	}
+
+	if len(content.Labels) > 0 {
+		req.Labels = &content.Labels
+	}
+
+	if len(content.Assignees) > 0 {
+		req.Assignees = &content.Assignees
+	}
+
+	issue, err := impl.client.CreateIssue(impl.community.Name, repo, req)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.Itoa(issue.GetNumber()), nil
+}
+
+// UpdateIssue 更新issue内容，issue已关闭时重新打开
+func (impl *githubImpl) UpdateIssue(repo, number string, content domain.IssueContent) error {
+	info, err := impl.issueInfo(repo, number)
+	if err != nil {
+		return err
+	}
+
+	state := issueStateOpen
+
+	return impl.client.UpdateIssue(info, &sdk.IssueRequest{
+		Title: &content.Title,
+		Body:  &content.Body,
+		State: &state,
+	})
+}
+
+func (impl *githubImpl) CloseIssue(repo, number string) error {
+	info, err := impl.issueInfo(repo, number)
+	if err != nil {
+		return err
+	}
+
+	return impl.client.CloseIssue(info)
+}
+
+func (impl *githubImpl) issueInfo(repo, number string) (client.PRInfo, error) {
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return client.PRInfo{}, err
+	}
+
+	return client.PRInfo{Org: impl.community.Name, Repo: repo, Number: n}, nil
+}
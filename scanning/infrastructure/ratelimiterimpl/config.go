@@ -0,0 +1,75 @@
+package ratelimiterimpl
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/opensourceways/image-scanning/utils"
+)
+
+const registryDocker = "docker.io"
+
+// Config 被镜像站限速且没有Retry-After时从Backoff开始指数退避，暂停时间不超过MaxBackoff；
+// DockerHubProbe为查询Docker Hub剩余拉取次数的间隔，时长格式同StringToInterval
+type Config struct {
+	Registries     []Registry `json:"registries"`
+	Backoff        string     `json:"backoff"`
+	MaxBackoff     string     `json:"max_backoff"`
+	DockerHubProbe string     `json:"docker_hub_probe"`
+}
+
+// Registry Rate为每分钟的请求数，为0时不限速，Burst为允许连续发出的请求数，
+// MaxConcurrency为同时进行的请求和拉取数，为0时不限制。没有配置的镜像站只在被限速后暂停
+type Registry struct {
+	Name           string  `json:"name"`
+	Rate           float64 `json:"rate"`
+	Burst          int     `json:"burst"`
+	MaxConcurrency int     `json:"max_concurrency"`
+}
+
+func (cfg *Config) SetDefault() {
+	if len(cfg.Registries) == 0 {
+		// docker的api每分钟限速180次，这里设置大概每分钟访问120次
+		cfg.Registries = []Registry{
+			{Name: registryDocker, Rate: 120, MaxConcurrency: 2},
+			{Name: "quay.io", Rate: 300, MaxConcurrency: 4},
+		}
+	}
+
+	if cfg.Backoff == "" {
+		cfg.Backoff = "1m"
+	}
+
+	if cfg.MaxBackoff == "" {
+		cfg.MaxBackoff = "1h"
+	}
+
+	if cfg.DockerHubProbe == "" {
+		cfg.DockerHubProbe = "5m"
+	}
+}
+
+func (cfg *Config) Validate() error {
+	if err := utils.ValidateDurations(cfg.Backoff, cfg.MaxBackoff, cfg.DockerHubProbe); err != nil {
+		return err
+	}
+
+	names := make(map[string]bool, len(cfg.Registries))
+	for _, r := range cfg.Registries {
+		if r.Name == "" {
+			return errors.New("missing name of registry")
+		}
+
+		if names[r.Name] {
+			return fmt.Errorf("duplicate registry %s", r.Name)
+		}
+
+		names[r.Name] = true
+
+		if r.Rate < 0 || r.Burst < 0 || r.MaxConcurrency < 0 {
+			return fmt.Errorf("rate, burst and max_concurrency of registry %s must not be negative", r.Name)
+		}
+	}
+
+	return nil
+}
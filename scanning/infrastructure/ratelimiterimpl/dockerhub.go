@@ -0,0 +1,76 @@
+package ratelimiterimpl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// 查询拉取次数使用Docker提供的测试镜像，HEAD请求不计入拉取次数，
+// reference: https://docs.docker.com/docker-hub/usage/pulls/#view-pull-rate-and-limit
+const (
+	dockerHubToken    = "https://auth.docker.io/token?service=registry.docker.io&scope=repository:ratelimitpreview/test:pull"
+	dockerHubManifest = "https://registry-1.docker.io/v2/ratelimitpreview/test/manifests/latest"
+
+	probeTimeout = 10 * time.Second
+)
+
+// probeDockerHub 以匿名用户查询本机IP剩余的拉取次数
+func probeDockerHub(ctx context.Context) (http.Header, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	token, err := dockerHubAnonymousToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, dockerHubManifest, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("response has status:%s", resp.Status)
+	}
+
+	return resp.Header, nil
+}
+
+func dockerHubAnonymousToken(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dockerHubToken, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("get token failed, response has status:%s", resp.Status)
+	}
+
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+
+	return body.Token, nil
+}
@@ -0,0 +1,106 @@
+package ratelimiterimpl
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Docker Hub的镜像仓库返回 RateLimit-Limit: 100;w=21600 和 RateLimit-Remaining: 76;w=21600，
+// w为时间窗口的秒数；Hub的接口和其他镜像站返回X-RateLimit-*，Reset为恢复的Unix时间
+var (
+	headersOfRemaining = []string{"RateLimit-Remaining", "X-RateLimit-Remaining"}
+	headersOfLimit     = []string{"RateLimit-Limit", "X-RateLimit-Limit"}
+	headersOfReset     = []string{"RateLimit-Reset", "X-RateLimit-Reset"}
+)
+
+// remaining 返回剩余的请求次数，没有相关头部时返回-1
+func remaining(header http.Header) int {
+	if v, ok := firstHeader(header, headersOfRemaining); ok {
+		if n, _, ok := parseQuota(v); ok {
+			return n
+		}
+	}
+
+	return -1
+}
+
+// pauseOf 优先使用Retry-After，其次是配额恢复的时间，都没有时按时间窗口估算恢复一次请求需要的时间，
+// 无法确定时返回0
+func pauseOf(header http.Header, now time.Time) time.Duration {
+	if d, ok := retryAfter(header, now); ok {
+		return d
+	}
+
+	if remaining(header) != 0 {
+		return 0
+	}
+
+	if v, ok := firstHeader(header, headersOfReset); ok {
+		if sec, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+			// 有的镜像站返回距离恢复的秒数，有的返回Unix时间
+			if reset := time.Unix(sec, 0); reset.After(now) {
+				return reset.Sub(now)
+			}
+
+			return time.Duration(sec) * time.Second
+		}
+	}
+
+	if v, ok := firstHeader(header, headersOfLimit); ok {
+		if n, window, ok := parseQuota(v); ok && n > 0 && window > 0 {
+			return window / time.Duration(n)
+		}
+	}
+
+	return 0
+}
+
+// retryAfter 支持秒数和HTTP日期两种格式
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	v := strings.TrimSpace(header.Get("Retry-After"))
+	if v == "" {
+		return 0, false
+	}
+
+	if sec, err := strconv.Atoi(v); err == nil {
+		return time.Duration(sec) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		return t.Sub(now), true
+	}
+
+	return 0, false
+}
+
+// parseQuota 解析 100;w=21600 格式的配额，没有时间窗口时window为0
+func parseQuota(v string) (n int, window time.Duration, ok bool) {
+	parts := strings.Split(v, ";")
+
+	n, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, false
+	}
+
+	for _, p := range parts[1:] {
+		if w, found := strings.CutPrefix(strings.TrimSpace(p), "w="); found {
+			if sec, err := strconv.Atoi(w); err == nil {
+				window = time.Duration(sec) * time.Second
+			}
+		}
+	}
+
+	return n, window, true
+}
+
+func firstHeader(header http.Header, keys []string) (string, bool) {
+	for _, k := range keys {
+		if v := header.Get(k); v != "" {
+			return v, true
+		}
+	}
+
+	return "", false
+}
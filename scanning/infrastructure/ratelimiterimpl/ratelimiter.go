@@ -0,0 +1,268 @@
+package ratelimiterimpl
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/image-scanning/scanning/infrastructure/metrics"
+	"github.com/opensourceways/image-scanning/utils"
+)
+
+// NewRateLimiter 所有社区的标签查询和镜像拉取共用一个实例
+func NewRateLimiter(cfg *Config) *rateLimiter {
+	r := &rateLimiter{
+		backoff:    utils.StringToDuration(cfg.Backoff),
+		maxBackoff: utils.StringToDuration(cfg.MaxBackoff),
+		registries: make(map[string]*registryLimiter),
+		probes: map[string]probe{
+			registryDocker: {interval: utils.StringToDuration(cfg.DockerHubProbe), run: probeDockerHub},
+		},
+	}
+
+	for _, c := range cfg.Registries {
+		r.registries[c.Name] = newRegistryLimiter(&c)
+	}
+
+	return r
+}
+
+type rateLimiter struct {
+	backoff    time.Duration
+	maxBackoff time.Duration
+
+	mu         sync.Mutex
+	registries map[string]*registryLimiter
+	probes     map[string]probe
+}
+
+// probe 查询镜像站剩余的请求次数，返回带有限速头部的响应头
+type probe struct {
+	interval time.Duration
+	run      func(ctx context.Context) (http.Header, error)
+}
+
+// registryLimiter 按GCRA算法限速：tat为理论上下一个请求的时间，允许提前tolerance；
+// pausedUntil之前不发出请求，throttles为连续被限速的次数
+type registryLimiter struct {
+	slots     chan struct{}
+	interval  time.Duration
+	tolerance time.Duration
+
+	tat         time.Time
+	pausedUntil time.Time
+	throttles   int
+	lastProbe   time.Time
+}
+
+func newRegistryLimiter(c *Registry) *registryLimiter {
+	l := &registryLimiter{}
+	if c.MaxConcurrency > 0 {
+		l.slots = make(chan struct{}, c.MaxConcurrency)
+	}
+
+	if c.Rate > 0 {
+		l.interval = time.Duration(float64(time.Minute) / c.Rate)
+		l.tolerance = time.Duration(max(c.Burst, 1)) * l.interval
+	}
+
+	return l
+}
+
+func (r *rateLimiter) get(registry string) *registryLimiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.registries[registry]
+	if !ok {
+		l = &registryLimiter{}
+		r.registries[registry] = l
+	}
+
+	return l
+}
+
+// Acquire 先等待暂停结束再占用并发名额，避免暂停期间占住名额，
+// 占用名额后按限速排队，此时的等待时间一般较短
+func (r *rateLimiter) Acquire(ctx context.Context, registry string) (func(), error) {
+	l := r.get(registry)
+
+	r.probe(ctx, registry, l)
+
+	if err := r.waitPause(ctx, l); err != nil {
+		return nil, err
+	}
+
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			if l.slots != nil {
+				<-l.slots
+			}
+		})
+	}
+
+	if err := sleep(ctx, r.reserve(l)); err != nil {
+		release()
+
+		return nil, err
+	}
+
+	return release, nil
+}
+
+// waitPause 等待期间暂停可能被其他请求的限速结果延长，直到不再暂停才返回
+func (r *rateLimiter) waitPause(ctx context.Context, l *registryLimiter) error {
+	for {
+		r.mu.Lock()
+		d := time.Until(l.pausedUntil)
+		r.mu.Unlock()
+
+		if d <= 0 {
+			return nil
+		}
+
+		if err := sleep(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// reserve 返回需要等待的时间，暂停期间的请求在暂停结束后依次发出
+func (r *rateLimiter) reserve(l *registryLimiter) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	start := later(now, l.pausedUntil)
+
+	if l.interval == 0 {
+		return start.Sub(now)
+	}
+
+	tat := later(l.tat, start).Add(l.interval)
+	l.tat = tat
+
+	return later(start, tat.Add(-l.tolerance)).Sub(now)
+}
+
+// probe 定期查询剩余的请求次数，查询失败时不影响请求
+func (r *rateLimiter) probe(ctx context.Context, registry string, l *registryLimiter) {
+	p, ok := r.probes[registry]
+	if !ok || p.interval <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	due := time.Since(l.lastProbe) >= p.interval
+	if due {
+		l.lastProbe = time.Now()
+	}
+	r.mu.Unlock()
+
+	if !due {
+		return
+	}
+
+	header, err := p.run(ctx)
+	if err != nil {
+		logrus.Warnf("probe rate limit of %s failed: %s", registry, err.Error())
+		return
+	}
+
+	r.Observe(registry, http.StatusOK, header)
+}
+
+func (r *rateLimiter) Observe(registry string, code int, header http.Header) {
+	throttled := code == http.StatusTooManyRequests
+	exhausted := !throttled && remaining(header) == 0
+	if !throttled && !exhausted {
+		if code >= 200 && code < 300 {
+			r.reset(registry)
+		}
+
+		return
+	}
+
+	l := r.get(registry)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d := pauseOf(header, time.Now())
+	if throttled {
+		l.throttles++
+
+		if d <= 0 {
+			d = r.backoffOf(l.throttles)
+		}
+	}
+
+	if d <= 0 {
+		// 配额用完但不知道什么时候恢复，暂停一次退避时间
+		d = r.backoff
+	}
+
+	d = min(d, r.maxBackoff)
+	until := time.Now().Add(d)
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+		logrus.Warnf("registry %s is rate limited, pause for %s", registry, d)
+	}
+
+	if throttled {
+		metrics.IncThrottled(registry)
+	}
+}
+
+func (r *rateLimiter) reset(registry string) {
+	l := r.get(registry)
+
+	r.mu.Lock()
+	l.throttles = 0
+	r.mu.Unlock()
+}
+
+func (r *rateLimiter) backoffOf(throttles int) time.Duration {
+	d := r.backoff
+	for i := 1; i < throttles && d < r.maxBackoff; i++ {
+		d *= 2
+	}
+
+	return d
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,110 @@
+package ratelimiterimpl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const testRegistry = "quay.io"
+
+func newTestLimiter(maxConcurrency int) *rateLimiter {
+	cfg := Config{Registries: []Registry{{Name: testRegistry, MaxConcurrency: maxConcurrency}}}
+	cfg.SetDefault()
+
+	return NewRateLimiter(&cfg)
+}
+
+func TestAcquireWaitsPauseWithoutSlot(t *testing.T) {
+	r := newTestLimiter(1)
+	l := r.get(testRegistry)
+
+	r.mu.Lock()
+	l.pausedUntil = time.Now().Add(200 * time.Millisecond)
+	r.mu.Unlock()
+
+	acquired := make(chan func(), 1)
+	go func() {
+		release, err := r.Acquire(context.Background(), testRegistry)
+		if err != nil {
+			t.Error(err)
+		}
+
+		acquired <- release
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+
+	// 暂停期间不占用并发名额
+	if n := len(l.slots); n != 0 {
+		t.Fatalf("slot should not be held during the pause, got %d", n)
+	}
+
+	select {
+	case release := <-acquired:
+		if n := len(l.slots); n != 1 {
+			t.Errorf("slot should be held after the pause, got %d", n)
+		}
+
+		release()
+		release()
+
+		if n := len(l.slots); n != 0 {
+			t.Errorf("slot should be released once, got %d", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("acquire is not returned after the pause")
+	}
+}
+
+func TestAcquireCanceledDuringPause(t *testing.T) {
+	r := newTestLimiter(1)
+	r.Observe(testRegistry, http.StatusTooManyRequests, http.Header{"Retry-After": {"60"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := r.Acquire(ctx, testRegistry); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want deadline exceeded, got %v", err)
+	}
+
+	if n := len(r.get(testRegistry).slots); n != 0 {
+		t.Errorf("canceled acquire should not hold a slot, got %d", n)
+	}
+}
+
+func TestObservePause(t *testing.T) {
+	r := newTestLimiter(0)
+	l := r.get(testRegistry)
+
+	pause := func() time.Duration {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		return time.Until(l.pausedUntil).Round(time.Minute)
+	}
+
+	// 没有Retry-After时指数退避
+	r.Observe(testRegistry, http.StatusTooManyRequests, nil)
+	if d := pause(); d != time.Minute {
+		t.Errorf("first throttle: want 1m, got %s", d)
+	}
+
+	r.Observe(testRegistry, http.StatusTooManyRequests, nil)
+	if d := pause(); d != 2*time.Minute {
+		t.Errorf("second throttle: want 2m, got %s", d)
+	}
+
+	// 暂停不超过MaxBackoff
+	r.Observe(testRegistry, http.StatusTooManyRequests, http.Header{"Retry-After": {"86400"}})
+	if d := pause(); d != time.Hour {
+		t.Errorf("want pause capped at 1h, got %s", d)
+	}
+
+	r.Observe(testRegistry, http.StatusOK, nil)
+	if l.throttles != 0 {
+		t.Errorf("success should reset throttles, got %d", l.throttles)
+	}
+}
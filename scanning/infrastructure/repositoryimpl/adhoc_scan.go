@@ -0,0 +1,141 @@
+package repositoryimpl
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/opensourceways/image-scanning/common/infrastructure/postgresql"
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+)
+
+// claimAdhocSQL 租约过期的扫描说明执行的实例已经退出，重新领取；SKIP LOCKED保证不会重复领取
+const claimAdhocSQL = `UPDATE adhoc_scan SET status = @running, lease_owner = @owner, lease_until = @until
+WHERE id IN (
+	SELECT id FROM adhoc_scan
+	WHERE (status = @queued OR (status = @running AND lease_until < @now)) AND (archive_format = '' OR node = @node)
+	ORDER BY created_at
+	LIMIT 1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING *`
+
+func NewAdhocScanImpl() *adhocScanImpl {
+	do := &AdhocScanDO{}
+	if err := postgresql.DB().AutoMigrate(do); err != nil {
+		logrus.Fatalf("auto migrate table %s failed: %v", do.TableName(), err)
+	}
+
+	return &adhocScanImpl{
+		Impl: postgresql.DAO(do.TableName()),
+	}
+}
+
+type adhocScanImpl struct {
+	postgresql.Impl
+}
+
+func (impl *adhocScanImpl) Add(scan *domain.AdhocScan) error {
+	do := ToAdhocScanDO(scan)
+
+	return impl.DB().Create(&do).Error
+}
+
+func (impl *adhocScanImpl) Find(id string) (domain.AdhocScan, error) {
+	var do AdhocScanDO
+	if err := impl.DB().Where(fieldId+" = ?", id).First(&do).Error; err != nil {
+		return domain.AdhocScan{}, err
+	}
+
+	return do.ToAdhocScan(), nil
+}
+
+func (impl *adhocScanImpl) CountQueued() (int64, error) {
+	var total int64
+	err := impl.DB().Where(fieldStatus+" = ?", domain.AdhocStatusQueued).Count(&total).Error
+
+	return total, err
+}
+
+func (impl *adhocScanImpl) Claim(owner, node string, lease time.Duration) ([]domain.AdhocScan, error) {
+	now := time.Now()
+
+	var dos []AdhocScanDO
+	err := impl.DB().Raw(claimAdhocSQL, map[string]interface{}{
+		"owner":   owner,
+		"node":    node,
+		"until":   now.Add(lease),
+		"now":     now,
+		"queued":  domain.AdhocStatusQueued,
+		"running": domain.AdhocStatusRunning,
+	}).Scan(&dos).Error
+	if err != nil {
+		return nil, err
+	}
+
+	scans := make([]domain.AdhocScan, len(dos))
+	for i := range dos {
+		scans[i] = dos[i].ToAdhocScan()
+	}
+
+	return scans, nil
+}
+
+func (impl *adhocScanImpl) RenewLease(id, owner string, lease time.Duration) error {
+	return impl.updateLeased(id, owner, impl.DB(), map[string]interface{}{
+		fieldLeaseUntil: time.Now().Add(lease),
+	})
+}
+
+// Complete 写入扫描结果并释放租约
+func (impl *adhocScanImpl) Complete(scan *domain.AdhocScan, owner string) error {
+	// 使用结构体更新，扫描结果需要经过json序列化
+	do := ToAdhocScanDO(scan)
+	do.LeaseOwner = ""
+	do.LeaseUntil = time.Time{}
+
+	return impl.updateLeased(scan.Id, owner, impl.DB().Select(
+		fieldStatus, "error", "arches", "report", "finished_at", fieldLeaseOwner, fieldLeaseUntil,
+	), &do)
+}
+
+func (impl *adhocScanImpl) updateLeased(id, owner string, query *gorm.DB, values interface{}) error {
+	result := query.Where(fieldId+" = ? AND "+fieldLeaseOwner+" = ?", id, owner).Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return repository.ErrLeaseLost
+	}
+
+	return nil
+}
+
+func (impl *adhocScanImpl) FailQueued(node, reason string) ([]domain.AdhocScan, error) {
+	var dos []AdhocScanDO
+	err := impl.DB().Model(&dos).Clauses(clause.Returning{}).
+		Where(fieldNode+" = ? AND "+fieldStatus+" = ?", node, domain.AdhocStatusQueued).
+		Updates(map[string]interface{}{
+			fieldStatus:   domain.AdhocStatusFailed,
+			"error":       reason,
+			"finished_at": time.Now(),
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	scans := make([]domain.AdhocScan, len(dos))
+	for i := range dos {
+		scans[i] = dos[i].ToAdhocScan()
+	}
+
+	return scans, nil
+}
+
+func (impl *adhocScanImpl) DeleteBefore(t time.Time) error {
+	return impl.DB().Where(fieldCreatedAt+" < ?", t).Delete(&AdhocScanDO{}).Error
+}
@@ -0,0 +1,81 @@
+package repositoryimpl
+
+import (
+	"time"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/primitive"
+)
+
+const fieldNode = "node"
+
+type AdhocScanDO struct {
+	Id            string              `gorm:"column:id;primaryKey"`
+	Registry      string              `gorm:"column:registry;comment:镜像站，上传的镜像包为空"`
+	Namespace     string              `gorm:"column:namespace;comment:命名空间"`
+	Image         string              `gorm:"column:image;comment:镜像名称或者上传时指定的名称"`
+	Tag           string              `gorm:"column:tag;comment:镜像标签"`
+	ArchiveFormat string              `gorm:"column:archive_format;comment:上传的镜像包格式"`
+	Node          string              `gorm:"column:node;comment:保存上传镜像包的实例"`
+	Arch          []string            `gorm:"column:arch;type:jsonb;serializer:json;comment:需要扫描的架构"`
+	Status        string              `gorm:"column:status;index;comment:扫描状态"`
+	Error         string              `gorm:"column:error;comment:失败原因"`
+	Arches        []domain.ArchRecord `gorm:"column:arches;type:jsonb;serializer:json;comment:各架构扫描结果"`
+	Report        string              `gorm:"column:report;comment:扫描报告"`
+	LeaseOwner    string              `gorm:"column:lease_owner;comment:持有租约的worker"`
+	LeaseUntil    time.Time           `gorm:"column:lease_until;comment:租约到期时间"`
+	CreatedAt     time.Time           `gorm:"column:created_at;index;<-:create"`
+	FinishedAt    time.Time           `gorm:"column:finished_at;comment:结束时间"`
+}
+
+func (do *AdhocScanDO) TableName() string {
+	return "adhoc_scan"
+}
+
+func ToAdhocScanDO(scan *domain.AdhocScan) AdhocScanDO {
+	do := AdhocScanDO{
+		Id:            scan.Id,
+		Namespace:     scan.Namespace,
+		Image:         scan.Image,
+		Tag:           scan.Tag,
+		ArchiveFormat: scan.ArchiveFormat,
+		Node:          scan.Node,
+		Arch:          scan.Arch,
+		Status:        scan.Status,
+		Error:         scan.Error,
+		Arches:        scan.Arches,
+		Report:        scan.Report,
+		CreatedAt:     scan.CreatedAt,
+		FinishedAt:    scan.FinishedAt,
+	}
+
+	if scan.Registry != nil {
+		do.Registry = scan.Registry.String()
+	}
+
+	return do
+}
+
+func (do *AdhocScanDO) ToAdhocScan() domain.AdhocScan {
+	scan := domain.AdhocScan{
+		Id:            do.Id,
+		Namespace:     do.Namespace,
+		Image:         do.Image,
+		Tag:           do.Tag,
+		ArchiveFormat: do.ArchiveFormat,
+		Node:          do.Node,
+		Arch:          do.Arch,
+		Status:        do.Status,
+		Error:         do.Error,
+		Arches:        do.Arches,
+		Report:        do.Report,
+		CreatedAt:     do.CreatedAt,
+		FinishedAt:    do.FinishedAt,
+	}
+
+	if do.Registry != "" {
+		scan.Registry = primitive.CreateRegistry(do.Registry)
+	}
+
+	return scan
+}
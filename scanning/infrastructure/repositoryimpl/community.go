@@ -0,0 +1,49 @@
+package repositoryimpl
+
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/opensourceways/image-scanning/common/infrastructure/postgresql"
+)
+
+func NewCommunityImpl() *communityImpl {
+	do := &CommunityDO{}
+	if err := postgresql.DB().AutoMigrate(do); err != nil {
+		logrus.Fatalf("auto migrate table %s failed: %v", do.TableName(), err)
+	}
+
+	return &communityImpl{
+		Impl: postgresql.DAO(do.TableName()),
+	}
+}
+
+type communityImpl struct {
+	postgresql.Impl
+}
+
+// IsPaused 没有记录的社区视为未暂停
+func (impl *communityImpl) IsPaused(name string) (bool, error) {
+	var do CommunityDO
+	err := impl.DB().Where(fieldName+" = ?", name).First(&do).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+
+	return do.Paused, err
+}
+
+func (impl *communityImpl) SetPaused(name string, paused bool) error {
+	do := CommunityDO{
+		Name:   name,
+		Paused: paused,
+	}
+
+	return impl.DB().Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: fieldName}},
+		DoUpdates: clause.AssignmentColumns([]string{fieldPaused, fieldUpdatedAt}),
+	}).Create(&do).Error
+}
@@ -0,0 +1,18 @@
+package repositoryimpl
+
+import "time"
+
+const (
+	fieldName      = "name"
+	fieldUpdatedAt = "updated_at"
+)
+
+type CommunityDO struct {
+	Name      string    `gorm:"column:name;primaryKey;comment:社区"`
+	Paused    bool      `gorm:"column:paused;comment:是否暂停扫描"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+func (do *CommunityDO) TableName() string {
+	return "community"
+}
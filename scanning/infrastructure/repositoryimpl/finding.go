@@ -0,0 +1,111 @@
+package repositoryimpl
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/opensourceways/image-scanning/common/infrastructure/postgresql"
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+)
+
+const findingBatchSize = 500
+
+func NewFindingImpl() *findingImpl {
+	do := &FindingDO{}
+	if err := postgresql.DB().AutoMigrate(do); err != nil {
+		logrus.Fatalf("auto migrate table %s failed: %v", do.TableName(), err)
+	}
+
+	return &findingImpl{
+		Impl: postgresql.DAO(do.TableName()),
+	}
+}
+
+type findingImpl struct {
+	postgresql.Impl
+}
+
+func (impl *findingImpl) Replace(taskId int64, keepArches []string, findings []domain.Finding) error {
+	dos := make([]FindingDO, len(findings))
+	for i := range findings {
+		dos[i] = ToFindingDO(&findings[i])
+	}
+
+	return impl.DB().Transaction(func(tx *gorm.DB) error {
+		query := tx.Where(fieldTaskId+" = ?", taskId)
+		if len(keepArches) > 0 {
+			query = query.Where(fieldArch+" NOT IN ?", keepArches)
+		}
+
+		if err := query.Delete(&FindingDO{}).Error; err != nil {
+			return err
+		}
+
+		if len(dos) == 0 {
+			return nil
+		}
+
+		return tx.CreateInBatches(dos, findingBatchSize).Error
+	})
+}
+
+func (impl *findingImpl) DeleteByTaskIds(ids []int64) error {
+	return impl.DB().Where(fieldTaskId+" IN ?", ids).Delete(&FindingDO{}).Error
+}
+
+func (impl *findingImpl) List(opt *repository.FindingListOption) ([]domain.Finding, int64, error) {
+	query := impl.DB().Where(FindingDO{
+		Community:       opt.Community,
+		VulnerabilityId: opt.VulnerabilityID,
+		PkgName:         opt.PkgName,
+	})
+
+	if len(opt.Severities) > 0 {
+		query = query.Where(fieldSeverity+" IN ?", opt.Severities)
+	}
+
+	if opt.Fixable != nil {
+		if *opt.Fixable {
+			query = query.Where(fieldFixedVersion + " <> ''")
+		} else {
+			query = query.Where(fieldFixedVersion + " = ''")
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var dos []FindingDO
+	order := fieldVulnerabilityId + ", " + fieldTaskId + ", " + fieldArch + ", " + fieldPkgName
+	if err := paginate(query.Order(order), opt.Page, opt.Size).Find(&dos).Error; err != nil {
+		return nil, 0, err
+	}
+
+	findings := make([]domain.Finding, len(dos))
+	for i := range dos {
+		findings[i] = dos[i].ToFinding()
+	}
+
+	return findings, total, nil
+}
+
+func (impl *findingImpl) IsEmpty() (bool, error) {
+	var do FindingDO
+	err := impl.DB().Select(fieldId).Limit(1).Find(&do).Error
+
+	return do.Id == 0, err
+}
+
+func (impl *findingImpl) CountBySeverity() ([]domain.SeverityCount, error) {
+	var counts []domain.SeverityCount
+	err := impl.DB().
+		Select(fieldCommunity + ", " + fieldSeverity + ", COUNT(DISTINCT (" +
+			fieldTaskId + ", " + fieldVulnerabilityId + ", " + fieldPkgName + ")) AS count").
+		Group(fieldCommunity + ", " + fieldSeverity).
+		Scan(&counts).Error
+
+	return counts, err
+}
@@ -0,0 +1,69 @@
+package repositoryimpl
+
+import (
+	"time"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+const (
+	fieldArch            = "arch"
+	fieldVulnerabilityId = "vulnerability_id"
+	fieldPkgName         = "pkg_name"
+	fieldSeverity        = "severity"
+	fieldFixedVersion    = "fixed_version"
+)
+
+type FindingDO struct {
+	Id               int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	TaskId           int64     `gorm:"column:task_id;index;comment:任务id"`
+	RecordId         int64     `gorm:"column:record_id;comment:扫描记录id"`
+	Community        string    `gorm:"column:community;comment:社区"`
+	Image            string    `gorm:"column:image;comment:镜像"`
+	Arch             string    `gorm:"column:arch;comment:架构"`
+	Digest           string    `gorm:"column:digest;comment:镜像digest"`
+	VulnerabilityId  string    `gorm:"column:vulnerability_id;index;comment:漏洞id"`
+	PkgName          string    `gorm:"column:pkg_name;index;comment:软件包"`
+	InstalledVersion string    `gorm:"column:installed_version;comment:安装版本"`
+	FixedVersion     string    `gorm:"column:fixed_version;comment:修复版本"`
+	Severity         string    `gorm:"column:severity;index;comment:严重级别"`
+	ScannedAt        time.Time `gorm:"column:scanned_at;comment:扫描时间"`
+}
+
+func (do *FindingDO) TableName() string {
+	return "finding"
+}
+
+func ToFindingDO(f *domain.Finding) FindingDO {
+	return FindingDO{
+		TaskId:           f.TaskId,
+		RecordId:         f.RecordId,
+		Community:        f.Community,
+		Image:            f.Image,
+		Arch:             f.Arch,
+		Digest:           f.Digest,
+		VulnerabilityId:  f.VulnerabilityID,
+		PkgName:          f.PkgName,
+		InstalledVersion: f.InstalledVersion,
+		FixedVersion:     f.FixedVersion,
+		Severity:         f.Severity,
+		ScannedAt:        f.ScannedAt,
+	}
+}
+
+func (do *FindingDO) ToFinding() domain.Finding {
+	return domain.Finding{
+		TaskId:           do.TaskId,
+		RecordId:         do.RecordId,
+		Community:        do.Community,
+		Image:            do.Image,
+		Arch:             do.Arch,
+		Digest:           do.Digest,
+		VulnerabilityID:  do.VulnerabilityId,
+		PkgName:          do.PkgName,
+		InstalledVersion: do.InstalledVersion,
+		FixedVersion:     do.FixedVersion,
+		Severity:         do.Severity,
+		ScannedAt:        do.ScannedAt,
+	}
+}
@@ -0,0 +1,78 @@
+package repositoryimpl
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/opensourceways/image-scanning/common/infrastructure/postgresql"
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+func NewIssueImpl() *issueImpl {
+	do := &IssueDO{}
+	if err := postgresql.DB().AutoMigrate(do); err != nil {
+		logrus.Fatalf("auto migrate table %s failed: %v", do.TableName(), err)
+	}
+
+	return &issueImpl{
+		Impl: postgresql.DAO(do.TableName()),
+	}
+}
+
+type issueImpl struct {
+	postgresql.Impl
+}
+
+// Modify 先插入记录再加行锁，唯一索引保证多个副本不会各自插入，SELECT FOR UPDATE保证依次修改
+func (impl *issueImpl) Modify(community, key string, modify func(issue *domain.Issue) bool) error {
+	return impl.DB().Transaction(func(tx *gorm.DB) error {
+		placeholder := IssueDO{Community: community, IssueKey: key}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&placeholder).Error; err != nil {
+			return err
+		}
+
+		var do IssueDO
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where(fieldCommunity+" = ? AND "+fieldIssueKey+" = ?", community, key).First(&do).Error
+		if err != nil {
+			return err
+		}
+
+		issue := do.ToIssue()
+		if !modify(&issue) {
+			return nil
+		}
+
+		do = ToIssueDO(&issue)
+
+		return tx.Save(&do).Error
+	})
+}
+
+func (impl *issueImpl) Find(community, key string) (domain.Issue, error) {
+	var do IssueDO
+	err := impl.DB().Where(fieldCommunity+" = ? AND "+fieldIssueKey+" = ?", community, key).First(&do).Error
+	if err != nil {
+		return domain.Issue{}, err
+	}
+
+	return do.ToIssue(), nil
+}
+
+func (impl *issueImpl) FindOpen(community string) ([]domain.Issue, error) {
+	var dos []IssueDO
+	err := impl.DB().Order(fieldId).
+		Where(fieldCommunity+" = ? AND "+fieldState+" = ?", community, domain.IssueStateOpen).
+		Find(&dos).Error
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]domain.Issue, len(dos))
+	for i := range dos {
+		issues[i] = dos[i].ToIssue()
+	}
+
+	return issues, nil
+}
@@ -0,0 +1,59 @@
+package repositoryimpl
+
+import (
+	"time"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+const (
+	fieldCommunity = "community"
+	fieldIssueKey  = "issue_key"
+	fieldState     = "state"
+)
+
+type IssueDO struct {
+	Id        int64               `gorm:"column:id;primaryKey; autoIncrement"`
+	Community string              `gorm:"column:community;uniqueIndex:idx_issue_community_key;comment:社区"`
+	IssueKey  string              `gorm:"column:issue_key;uniqueIndex:idx_issue_community_key;comment:镜像地址或者漏洞ID"`
+	Repo      string              `gorm:"column:repo;comment:issue所在仓库"`
+	Number    string              `gorm:"column:number;comment:issue编号"`
+	State     string              `gorm:"column:state;comment:issue状态"`
+	Images    []string            `gorm:"column:images;type:jsonb;serializer:json;comment:受影响的镜像"`
+	Owners    map[string][]string `gorm:"column:owners;type:jsonb;serializer:json;comment:受影响镜像的负责人"`
+	Digest    string              `gorm:"column:content_digest;comment:最近一次写入issue的内容摘要"`
+	CreatedAt time.Time           `gorm:"column:created_at;<-:create"`
+	UpdatedAt time.Time           `gorm:"column:updated_at"`
+}
+
+func (do *IssueDO) TableName() string {
+	return "issue"
+}
+
+func ToIssueDO(issue *domain.Issue) IssueDO {
+	return IssueDO{
+		Id:        issue.Id,
+		Community: issue.Community,
+		IssueKey:  issue.Key,
+		Repo:      issue.Repo,
+		Number:    issue.Number,
+		State:     issue.State,
+		Images:    issue.Images,
+		Owners:    issue.Owners,
+		Digest:    issue.ContentDigest,
+	}
+}
+
+func (do *IssueDO) ToIssue() domain.Issue {
+	return domain.Issue{
+		Id:            do.Id,
+		Community:     do.Community,
+		Key:           do.IssueKey,
+		Repo:          do.Repo,
+		Number:        do.Number,
+		State:         do.State,
+		Images:        do.Images,
+		Owners:        do.Owners,
+		ContentDigest: do.Digest,
+	}
+}
@@ -0,0 +1,16 @@
+package repositoryimpl
+
+import "gorm.io/gorm"
+
+// paginate page从1开始，size不大于0时不分页
+func paginate(db *gorm.DB, page, size int) *gorm.DB {
+	if size <= 0 {
+		return db
+	}
+
+	if page < 1 {
+		page = 1
+	}
+
+	return db.Offset((page - 1) * size).Limit(size)
+}
@@ -0,0 +1,88 @@
+package repositoryimpl
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/image-scanning/common/infrastructure/postgresql"
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+func NewScanRecordImpl() *scanRecordImpl {
+	do := &ScanRecordDO{}
+	if err := postgresql.DB().AutoMigrate(do); err != nil {
+		logrus.Fatalf("auto migrate table %s failed: %v", do.TableName(), err)
+	}
+
+	return &scanRecordImpl{
+		Impl: postgresql.DAO(do.TableName()),
+	}
+}
+
+type scanRecordImpl struct {
+	postgresql.Impl
+}
+
+func (impl *scanRecordImpl) Save(record *domain.ScanRecord) error {
+	do := ToScanRecordDO(record)
+	if err := impl.DB().Create(&do).Error; err != nil {
+		return err
+	}
+
+	record.Id = do.Id
+	record.CreatedAt = do.CreatedAt
+
+	return nil
+}
+
+func (impl *scanRecordImpl) FindLatest(taskId int64) (domain.ScanRecord, error) {
+	var do ScanRecordDO
+	err := impl.DB().Where(ScanRecordDO{TaskId: taskId}).Order(fieldId + " desc").First(&do).Error
+	if err != nil {
+		return domain.ScanRecord{}, err
+	}
+
+	return do.ToScanRecord(), nil
+}
+
+// FindByTask 按扫描时间倒序分页查询任务的历史扫描结果
+func (impl *scanRecordImpl) FindByTask(taskId int64, page, size int) ([]domain.ScanRecord, int64, error) {
+	query := impl.DB().Where(ScanRecordDO{TaskId: taskId})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var dos []ScanRecordDO
+	if err := paginate(query.Order(fieldId+" desc"), page, size).Find(&dos).Error; err != nil {
+		return nil, 0, err
+	}
+
+	records := make([]domain.ScanRecord, len(dos))
+	for i := range dos {
+		records[i] = dos[i].ToScanRecord()
+	}
+
+	return records, total, nil
+}
+
+// FindLatestPerTask 查询社区每个任务在[from, to)时间范围内最新的扫描结果
+func (impl *scanRecordImpl) FindLatestPerTask(community string, from, to time.Time) ([]domain.ScanRecord, error) {
+	var dos []ScanRecordDO
+	err := impl.DB().Select("DISTINCT ON ("+fieldTaskId+") *").
+		Where(fieldCommunity+" = ? AND "+fieldCreatedAt+" >= ? AND "+fieldCreatedAt+" < ?", community, from, to).
+		Order(fieldTaskId + ", " + fieldId + " desc").
+		Find(&dos).Error
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]domain.ScanRecord, len(dos))
+	for i := range dos {
+		records[i] = dos[i].ToScanRecord()
+	}
+
+	return records, nil
+}
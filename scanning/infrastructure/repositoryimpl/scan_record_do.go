@@ -0,0 +1,67 @@
+package repositoryimpl
+
+import (
+	"time"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+const (
+	fieldTaskId    = "task_id"
+	fieldCreatedAt = "created_at"
+)
+
+type ScanRecordDO struct {
+	Id           int64               `gorm:"column:id;primaryKey; autoIncrement"`
+	TaskId       int64               `gorm:"column:task_id;index;comment:扫描任务id"`
+	Community    string              `gorm:"column:community;comment:社区"`
+	Image        string              `gorm:"column:image;comment:镜像地址"`
+	Owner        domain.Owner        `gorm:"column:owner;type:jsonb;serializer:json;comment:负责人"`
+	PolicyStatus string              `gorm:"column:policy_status;comment:策略检查结果，未配置策略时为空"`
+	Violations   []string            `gorm:"column:violations;type:jsonb;serializer:json;comment:违反的策略"`
+	Arches       []domain.ArchRecord `gorm:"column:arches;type:jsonb;serializer:json;comment:各架构扫描结果"`
+	CreatedAt    time.Time           `gorm:"column:created_at;<-:create"`
+}
+
+func (do *ScanRecordDO) TableName() string {
+	return "scan_record"
+}
+
+func ToScanRecordDO(record *domain.ScanRecord) ScanRecordDO {
+	do := ScanRecordDO{
+		Id:        record.Id,
+		TaskId:    record.TaskId,
+		Community: record.Community,
+		Image:     record.Image,
+		Owner:     record.Owner,
+		Arches:    record.Arches,
+	}
+
+	if record.Policy != nil {
+		do.PolicyStatus = record.Policy.Status
+		do.Violations = record.Policy.Violations
+	}
+
+	return do
+}
+
+func (do *ScanRecordDO) ToScanRecord() domain.ScanRecord {
+	record := domain.ScanRecord{
+		Id:        do.Id,
+		TaskId:    do.TaskId,
+		Community: do.Community,
+		Image:     do.Image,
+		Owner:     do.Owner,
+		Arches:    do.Arches,
+		CreatedAt: do.CreatedAt,
+	}
+
+	if do.PolicyStatus != "" {
+		record.Policy = &domain.PolicyResult{
+			Status:     do.PolicyStatus,
+			Violations: do.Violations,
+		}
+	}
+
+	return record
+}
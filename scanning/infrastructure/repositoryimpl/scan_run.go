@@ -0,0 +1,70 @@
+package repositoryimpl
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/opensourceways/image-scanning/common/infrastructure/postgresql"
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+)
+
+func NewScanRunImpl() *scanRunImpl {
+	do := &ScanRunDO{}
+	if err := postgresql.DB().AutoMigrate(do); err != nil {
+		logrus.Fatalf("auto migrate table %s failed: %v", do.TableName(), err)
+	}
+
+	return &scanRunImpl{
+		Impl: postgresql.DAO(do.TableName()),
+	}
+}
+
+type scanRunImpl struct {
+	postgresql.Impl
+}
+
+func (impl *scanRunImpl) Add(run *domain.ScanRun) error {
+	do := ToScanRunDO(run)
+	if err := impl.DB().Create(&do).Error; err != nil {
+		return err
+	}
+
+	run.Id = do.Id
+
+	return nil
+}
+
+// List 按开始时间倒序分页查询
+func (impl *scanRunImpl) List(opt *repository.ScanRunListOption) ([]domain.ScanRun, int64, error) {
+	query := impl.DB().Where(ScanRunDO{
+		TaskId:    opt.TaskId,
+		Community: opt.Community,
+		Outcome:   opt.Outcome,
+	})
+
+	if !opt.From.IsZero() {
+		query = query.Where(fieldStartedAt+" >= ?", opt.From)
+	}
+
+	if !opt.To.IsZero() {
+		query = query.Where(fieldStartedAt+" < ?", opt.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var dos []ScanRunDO
+	err := paginate(query.Order(fieldStartedAt+" desc, "+fieldId+" desc"), opt.Page, opt.Size).Find(&dos).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	runs := make([]domain.ScanRun, len(dos))
+	for i := range dos {
+		runs[i] = dos[i].ToScanRun()
+	}
+
+	return runs, total, nil
+}
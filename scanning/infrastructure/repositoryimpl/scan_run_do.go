@@ -0,0 +1,74 @@
+package repositoryimpl
+
+import (
+	"time"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+)
+
+const (
+	fieldOutcome   = "outcome"
+	fieldStartedAt = "started_at"
+)
+
+type ScanRunDO struct {
+	Id               int64             `gorm:"column:id;primaryKey; autoIncrement"`
+	TaskId           int64             `gorm:"column:task_id;index;comment:扫描任务id"`
+	Community        string            `gorm:"column:community;comment:社区"`
+	Image            string            `gorm:"column:image;comment:镜像地址"`
+	Worker           string            `gorm:"column:worker;comment:执行扫描的实例"`
+	StartedAt        time.Time         `gorm:"column:started_at;index;comment:开始时间"`
+	FinishedAt       time.Time         `gorm:"column:finished_at;comment:结束时间"`
+	PullDuration     int64             `gorm:"column:pull_duration;comment:拉取镜像耗时，单位毫秒"`
+	ScanDuration     int64             `gorm:"column:scan_duration;comment:扫描耗时，单位毫秒"`
+	UploadDuration   int64             `gorm:"column:upload_duration;comment:上传报告耗时，单位毫秒"`
+	BytesPulled      int64             `gorm:"column:bytes_pulled;comment:拉取的镜像大小"`
+	TrivyDBUpdatedAt time.Time         `gorm:"column:trivy_db_updated_at;comment:漏洞库更新时间"`
+	Digests          map[string]string `gorm:"column:digests;type:jsonb;serializer:json;comment:各架构镜像摘要"`
+	Outcome          string            `gorm:"column:outcome;comment:执行结果"`
+	Error            string            `gorm:"column:error;comment:失败原因"`
+}
+
+func (do *ScanRunDO) TableName() string {
+	return "scan_run"
+}
+
+func ToScanRunDO(run *domain.ScanRun) ScanRunDO {
+	return ScanRunDO{
+		Id:               run.Id,
+		TaskId:           run.TaskId,
+		Community:        run.Community,
+		Image:            run.Image,
+		Worker:           run.Worker,
+		StartedAt:        run.StartedAt,
+		FinishedAt:       run.FinishedAt,
+		PullDuration:     run.PullDuration.Milliseconds(),
+		ScanDuration:     run.ScanDuration.Milliseconds(),
+		UploadDuration:   run.UploadDuration.Milliseconds(),
+		BytesPulled:      run.BytesPulled,
+		TrivyDBUpdatedAt: run.TrivyDBUpdatedAt,
+		Digests:          run.Digests,
+		Outcome:          run.Outcome,
+		Error:            run.Error,
+	}
+}
+
+func (do *ScanRunDO) ToScanRun() domain.ScanRun {
+	return domain.ScanRun{
+		Id:               do.Id,
+		TaskId:           do.TaskId,
+		Community:        do.Community,
+		Image:            do.Image,
+		Worker:           do.Worker,
+		StartedAt:        do.StartedAt,
+		FinishedAt:       do.FinishedAt,
+		PullDuration:     time.Duration(do.PullDuration) * time.Millisecond,
+		ScanDuration:     time.Duration(do.ScanDuration) * time.Millisecond,
+		UploadDuration:   time.Duration(do.UploadDuration) * time.Millisecond,
+		BytesPulled:      do.BytesPulled,
+		TrivyDBUpdatedAt: do.TrivyDBUpdatedAt,
+		Digests:          do.Digests,
+		Outcome:          do.Outcome,
+		Error:            do.Error,
+	}
+}
@@ -1,10 +1,13 @@
 package repositoryimpl
 
 import (
+	"time"
+
 	"github.com/sirupsen/logrus"
 
 	"github.com/opensourceways/image-scanning/common/infrastructure/postgresql"
 	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
 )
 
 func NewTaskImpl() *taskImpl {
@@ -13,6 +16,11 @@ func NewTaskImpl() *taskImpl {
 		logrus.Fatalf("auto migrate table %s failed: %v", do.TableName(), err)
 	}
 
+	// 升级前的任务没有下次扫描时间，按原来的扫描间隔和重试时间补齐
+	if err := postgresql.DB().Exec(fillNextScanTimeSQL).Error; err != nil {
+		logrus.Fatalf("fill next scan time of table %s failed: %v", do.TableName(), err)
+	}
+
 	return &taskImpl{
 		Impl: postgresql.DAO(do.TableName()),
 	}
@@ -22,10 +30,22 @@ type taskImpl struct {
 	postgresql.Impl
 }
 
+const fillNextScanTimeSQL = `UPDATE task SET next_scan_time = CASE WHEN failures > 0 THEN retry_at
+	ELSE last_scan_time + "interval" * INTERVAL '1 second' END
+WHERE next_scan_time IS NULL`
+
+// Save 暂停状态只通过SetPaused修改，租约和执行状态只通过任务队列修改，
+// 下次扫描时间只通过任务队列和Reschedule修改，避免生成任务时覆盖
 func (impl *taskImpl) Save(task domain.Task) error {
 	do := ToTaskDO(task)
 
-	return impl.DB().Save(&do).Error
+	omits := []string{fieldPaused, fieldLeaseOwner, fieldLeaseUntil, fieldScanRequested}
+	if do.Id != 0 {
+		omits = append(omits, fieldLastScanTime, fieldStatus, fieldLastError, fieldFailures, fieldRetryAt,
+			fieldNextScanTime)
+	}
+
+	return impl.DB().Omit(omits...).Save(&do).Error
 }
 
 func (impl *taskImpl) Find(task domain.Task) (domain.Task, error) {
@@ -71,6 +91,59 @@ func (impl *taskImpl) FindAll(name string) ([]domain.Task, error) {
 	return tasks, nil
 }
 
+func (impl *taskImpl) FindById(id int64) (domain.Task, error) {
+	var do TaskDO
+	if err := impl.DB().Where(fieldId+" = ?", id).First(&do).Error; err != nil {
+		return domain.Task{}, err
+	}
+
+	return do.ToTask(), nil
+}
+
+func (impl *taskImpl) List(opt *repository.TaskListOption) ([]domain.Task, int64, error) {
+	query := impl.DB().Where(TaskDO{
+		Community: opt.Community,
+		Registry:  opt.Registry,
+		Namespace: opt.Namespace,
+		Image:     opt.Image,
+		Tag:       opt.Tag,
+	})
+
+	if opt.Paused != nil {
+		query = query.Where(fieldPaused+" = ?", *opt.Paused)
+	}
+
+	if opt.Status != "" {
+		query = query.Where(fieldStatus+" = ?", opt.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var dos []TaskDO
+	if err := paginate(query.Order(fieldId), opt.Page, opt.Size).Find(&dos).Error; err != nil {
+		return nil, 0, err
+	}
+
+	tasks := make([]domain.Task, len(dos))
+	for i := range dos {
+		tasks[i] = dos[i].ToTask()
+	}
+
+	return tasks, total, nil
+}
+
+func (impl *taskImpl) SetPaused(ids []int64, paused bool) error {
+	return impl.DB().Where(fieldId+" IN ?", ids).Update(fieldPaused, paused).Error
+}
+
+// Reschedule 扫描计划变化后更新下次扫描时间
+func (impl *taskImpl) Reschedule(id int64, next time.Time) error {
+	return impl.DB().Where(fieldId+" = ?", id).Update(fieldNextScanTime, next).Error
+}
+
 func (impl *taskImpl) DeleteByIds(ids []int64) error {
 	return impl.DB().Delete(&TaskDO{}, ids).Error
 }
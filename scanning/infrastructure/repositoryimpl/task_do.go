@@ -9,21 +9,45 @@ import (
 )
 
 const (
-	fieldId = "id"
+	fieldId            = "id"
+	fieldPaused        = "paused"
+	fieldLastScanTime  = "last_scan_time"
+	fieldLeaseOwner    = "lease_owner"
+	fieldLeaseUntil    = "lease_until"
+	fieldScanRequested = "scan_requested"
+	fieldStatus        = "status"
+	fieldLastError     = "last_error"
+	fieldFailures      = "failures"
+	fieldRetryAt       = "retry_at"
+	fieldNextScanTime  = "next_scan_time"
 )
 
 type TaskDO struct {
-	Id           int64     `gorm:"column:id;primaryKey; autoIncrement"`
-	Community    string    `gorm:"column:community;comment:社区"`
-	Registry     string    `gorm:"column:registry;comment:镜像站"`
-	Namespace    string    `gorm:"column:namespace;comment:镜像站命名空间"`
-	Image        string    `gorm:"column:image;comment:镜像名"`
-	Tag          string    `gorm:"column:tag;comment:镜像tag"`
-	Arch         string    `gorm:"column:arch;comment:架构"`
-	Interval     int       `gorm:"column:interval;comment:扫描间隔，单位秒"`
-	LastScanTime time.Time `gorm:"column:last_scan_time;comment:上次扫描时间"`
-	CreatedAt    time.Time `gorm:"column:created_at;<-:create"`
-	UpdatedAt    time.Time `gorm:"column:updated_at;<-:update"`
+	Id            int64     `gorm:"column:id;primaryKey; autoIncrement"`
+	Community     string    `gorm:"column:community;comment:社区"`
+	Registry      string    `gorm:"column:registry;comment:镜像站"`
+	Namespace     string    `gorm:"column:namespace;comment:镜像站命名空间"`
+	Image         string    `gorm:"column:image;comment:镜像名"`
+	Tag           string    `gorm:"column:tag;comment:镜像tag"`
+	Arch          string    `gorm:"column:arch;comment:架构"`
+	Interval      int       `gorm:"column:interval;comment:扫描间隔，单位秒，使用cron时为0"`
+	Cron          string    `gorm:"column:cron;comment:cron表达式"`
+	Priority      int       `gorm:"column:priority;default:0;comment:优先级，越大越先扫描"`
+	Maintainers   string    `gorm:"column:maintainers;comment:负责人"`
+	Sig           string    `gorm:"column:sig;comment:负责的SIG"`
+	Contact       string    `gorm:"column:contact;comment:联系方式"`
+	Paused        bool      `gorm:"column:paused;comment:是否暂停扫描"`
+	LastScanTime  time.Time `gorm:"column:last_scan_time;comment:上次扫描时间"`
+	LeaseOwner    string    `gorm:"column:lease_owner;default:'';comment:领取任务的worker"`
+	LeaseUntil    time.Time `gorm:"column:lease_until;index;comment:租约到期时间"`
+	ScanRequested bool      `gorm:"column:scan_requested;default:false;comment:是否要求立即扫描"`
+	Status        string    `gorm:"column:status;default:pending;comment:执行状态"`
+	LastError     string    `gorm:"column:last_error;comment:最近一次失败的原因"`
+	Failures      int       `gorm:"column:failures;default:0;comment:连续失败次数"`
+	RetryAt       time.Time `gorm:"column:retry_at;comment:失败后的重试时间"`
+	NextScanTime  time.Time `gorm:"column:next_scan_time;index;comment:下次扫描时间"`
+	CreatedAt     time.Time `gorm:"column:created_at;<-:create"`
+	UpdatedAt     time.Time `gorm:"column:updated_at;<-:update"`
 }
 
 func (do *TaskDO) TableName() string {
@@ -40,7 +64,18 @@ func ToTaskDO(task domain.Task) TaskDO {
 		Tag:          task.Tag,
 		Arch:         strings.Join(task.Arch, ","),
 		Interval:     task.Interval,
+		Cron:         task.Cron,
+		Priority:     task.Priority,
+		Maintainers:  strings.Join(task.Owner.Maintainers, ","),
+		Sig:          task.Owner.Sig,
+		Contact:      task.Owner.Contact,
+		Paused:       task.Paused,
 		LastScanTime: task.LastScanTime,
+		Status:       task.Status,
+		LastError:    task.LastError,
+		Failures:     task.Failures,
+		RetryAt:      task.RetryAt,
+		NextScanTime: task.NextScanTime,
 	}
 }
 
@@ -54,6 +89,28 @@ func (do *TaskDO) ToTask() domain.Task {
 		Tag:          do.Tag,
 		Arch:         strings.Split(do.Arch, ","),
 		Interval:     do.Interval,
+		Cron:         do.Cron,
+		Priority:     do.Priority,
+		Owner:        do.toOwner(),
+		Paused:       do.Paused,
 		LastScanTime: do.LastScanTime,
+		Status:       do.Status,
+		LastError:    do.LastError,
+		Failures:     do.Failures,
+		RetryAt:      do.RetryAt,
+		NextScanTime: do.NextScanTime,
 	}
 }
+
+func (do *TaskDO) toOwner() domain.Owner {
+	owner := domain.Owner{
+		Sig:     do.Sig,
+		Contact: do.Contact,
+	}
+
+	if do.Maintainers != "" {
+		owner.Maintainers = strings.Split(do.Maintainers, ",")
+	}
+
+	return owner
+}
@@ -0,0 +1,156 @@
+package repositoryimpl
+
+import (
+	"time"
+
+	"github.com/opensourceways/image-scanning/scanning/domain"
+	"github.com/opensourceways/image-scanning/scanning/domain/repository"
+)
+
+// dueCondition 要求立即扫描的任务不受暂停、隔离状态、扫描窗口和扫描计划的限制，
+// 失败的任务的下次扫描时间即重试时间，升级前的任务租约字段为空
+const dueCondition = `community IN @communities AND (lease_until IS NULL OR lease_until < @now) AND (scan_requested OR (
+	community IN @scheduled AND NOT paused AND status <> 'quarantined' AND
+	community NOT IN (SELECT name FROM community WHERE paused) AND next_scan_time <= @now))`
+
+// claimSQL SKIP LOCKED保证多个worker、多个实例同时领取时不会拿到同一个任务
+const claimSQL = `UPDATE task SET lease_owner = @owner, lease_until = @until
+WHERE id IN (
+	SELECT id FROM task WHERE ` + dueCondition + `
+	ORDER BY scan_requested DESC, priority DESC, next_scan_time, id
+	LIMIT @limit
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING *`
+
+func (impl *taskImpl) Claim(opt *repository.ClaimOption) ([]domain.Task, error) {
+	if len(opt.Communities) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+
+	var dos []TaskDO
+	err := impl.DB().Raw(claimSQL, map[string]interface{}{
+		"owner":       opt.Owner,
+		"until":       now.Add(opt.Lease),
+		"communities": opt.Communities,
+		"scheduled":   scheduledArg(opt.Scheduled),
+		"now":         now,
+		"limit":       opt.Limit,
+	}).Scan(&dos).Error
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]domain.Task, len(dos))
+	for i := range dos {
+		tasks[i] = dos[i].ToTask()
+	}
+
+	return tasks, nil
+}
+
+func (impl *taskImpl) RenewLease(id int64, owner string, lease time.Duration) error {
+	return impl.updateLeased(id, owner, map[string]interface{}{
+		fieldLeaseUntil: time.Now().Add(lease),
+	})
+}
+
+// Complete 写入本次扫描的时间和结果并释放租约
+func (impl *taskImpl) Complete(task domain.Task, owner string) error {
+	return impl.updateLeased(task.Id, owner, map[string]interface{}{
+		fieldLastScanTime:  task.LastScanTime,
+		fieldStatus:        task.Status,
+		fieldLastError:     task.LastError,
+		fieldFailures:      task.Failures,
+		fieldRetryAt:       task.RetryAt,
+		fieldNextScanTime:  task.NextScanTime,
+		fieldLeaseOwner:    "",
+		fieldLeaseUntil:    time.Time{},
+		fieldScanRequested: false,
+	})
+}
+
+// Release 只释放租约并恢复执行状态，任务仍然到期，会被重新领取
+func (impl *taskImpl) Release(task domain.Task, owner string) error {
+	return impl.updateLeased(task.Id, owner, map[string]interface{}{
+		fieldStatus:     task.Status,
+		fieldLeaseOwner: "",
+		fieldLeaseUntil: time.Time{},
+	})
+}
+
+// SetStatus 更新正在执行的任务所处的阶段
+func (impl *taskImpl) SetStatus(id int64, owner, status string) error {
+	return impl.updateLeased(id, owner, map[string]interface{}{
+		fieldStatus: status,
+	})
+}
+
+func (impl *taskImpl) updateLeased(id int64, owner string, values map[string]interface{}) error {
+	result := impl.DB().Where(fieldId+" = ? AND "+fieldLeaseOwner+" = ?", id, owner).Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return repository.ErrLeaseLost
+	}
+
+	return nil
+}
+
+func (impl *taskImpl) RequestScan(id int64) error {
+	return impl.DB().Where(fieldId+" = ?", id).Update(fieldScanRequested, true).Error
+}
+
+type runningCount struct {
+	Community string
+	Total     int
+}
+
+func (impl *taskImpl) CountRunning(communities []string) (map[string]int, error) {
+	result := make(map[string]int, len(communities))
+	if len(communities) == 0 {
+		return result, nil
+	}
+
+	var counts []runningCount
+	err := impl.DB().Select("community, count(*) AS total").
+		Where("community IN ? AND "+fieldLeaseOwner+" <> '' AND "+fieldLeaseUntil+" >= ?", communities, time.Now()).
+		Group("community").Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range counts {
+		result[c.Community] = c.Total
+	}
+
+	return result, nil
+}
+
+func (impl *taskImpl) CountDue(communities, scheduled []string) (int64, error) {
+	if len(communities) == 0 {
+		return 0, nil
+	}
+
+	var total int64
+	err := impl.DB().Where(dueCondition, map[string]interface{}{
+		"communities": communities,
+		"scheduled":   scheduledArg(scheduled),
+		"now":         time.Now(),
+	}).Count(&total).Error
+
+	return total, err
+}
+
+// scheduledArg IN ()在PostgreSQL中是语法错误，没有社区处于扫描窗口时用一个不存在的社区名代替
+func scheduledArg(scheduled []string) []string {
+	if len(scheduled) == 0 {
+		return []string{""}
+	}
+
+	return scheduled
+}
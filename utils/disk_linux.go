@@ -0,0 +1,13 @@
+package utils
+
+import "syscall"
+
+// FreeDiskSpace 返回path所在文件系统非root用户可用的空间，单位字节
+func FreeDiskSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
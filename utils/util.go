@@ -1,12 +1,13 @@
 package utils
 
 import (
-	"bytes"
-	"errors"
 	"fmt"
+	"io/fs"
 	"os"
-	"os/exec"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"sigs.k8s.io/yaml"
 )
@@ -21,38 +22,67 @@ func LoadFromYaml(path string, cfg interface{}) error {
 	return yaml.Unmarshal(b, cfg)
 }
 
-// StringToInterval s support format: 24h,1d,1w,30m
+// StringToInterval s support format: 24h,1d,1w,30m, and compound durations like 1d12h
 func StringToInterval(s string) (int, error) {
-	length := len(s)
-	if length < 2 {
+	if len(s) < 2 {
 		return 0, fmt.Errorf("invalid duration format: %s", s)
 	}
 
-	num := s[:length-1]
-	unit := s[length-1]
+	interval := 0
+	for rest := s; rest != ""; {
+		i := strings.IndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' })
+		if i <= 0 {
+			return 0, fmt.Errorf("invalid number: %s", s)
+		}
 
-	multiplier, err := strconv.Atoi(num)
-	if err != nil {
-		return 0, fmt.Errorf("invalid number: %s", num)
-	}
+		multiplier, err := strconv.Atoi(rest[:i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid number: %s", rest[:i])
+		}
+
+		unit, ok := intervalUnits[rest[i]]
+		if !ok {
+			return 0, fmt.Errorf("unsupported unit type: %c", rest[i])
+		}
 
-	var interval int
-	switch unit {
-	case 'm':
-		interval = multiplier * 60
-	case 'h':
-		interval = multiplier * 60 * 60
-	case 'd':
-		interval = multiplier * 24 * 60 * 60
-	case 'w':
-		interval = multiplier * 7 * 24 * 60 * 60
-	default:
-		return 0, fmt.Errorf("unsupported unit type: %c", unit)
+		interval += multiplier * unit
+		rest = rest[i+1:]
 	}
 
 	return interval, nil
 }
 
+// ValidateDurations checks that every s is in the StringToInterval format and longer than zero,
+// a zero duration would make tickers panic and timeouts expire immediately.
+func ValidateDurations(ss ...string) error {
+	for _, s := range ss {
+		interval, err := StringToInterval(s)
+		if err != nil {
+			return err
+		}
+
+		if interval <= 0 {
+			return fmt.Errorf("duration must be positive: %s", s)
+		}
+	}
+
+	return nil
+}
+
+// StringToDuration converts a duration in the StringToInterval format, s must have been validated.
+func StringToDuration(s string) time.Duration {
+	v, _ := StringToInterval(s)
+
+	return time.Second * time.Duration(v)
+}
+
+var intervalUnits = map[byte]int{
+	'm': 60,
+	'h': 60 * 60,
+	'd': 24 * 60 * 60,
+	'w': 7 * 24 * 60 * 60,
+}
+
 func PathExists(path string) (bool, error) {
 	_, err := os.Stat(path)
 	if err == nil {
@@ -66,15 +96,23 @@ func PathExists(path string) (bool, error) {
 	return false, err
 }
 
-func RunCmd(c string, param ...string) (string, error) {
-	var out, stderr bytes.Buffer
-	cmd := exec.Command(c, param...)
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
+// DirSize 目录下所有普通文件大小之和
+func DirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
 
-	if err := cmd.Run(); err != nil {
-		return "", errors.New(stderr.String())
-	} else {
-		return out.String(), nil
-	}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		size += info.Size()
+
+		return nil
+	})
+
+	return size, err
 }